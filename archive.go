@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/jehiah/legislator/db"
+	"github.com/jehiah/legislator/legistar"
+)
+
+// archivePath returns the location of id in the nyc_legislation archive
+// i.e. introduction/2024/1234.json or resolution/2025/0707.json
+func archivePath(id IntroID) string {
+	dir := "introduction"
+	if id.Type() == "Resolution" {
+		dir = "resolution"
+	}
+	return fmt.Sprintf("%s/%d/%04d.json", dir, id.FileYear(), id.FileNumber())
+}
+
+// getArchivedLegislation returns the full record for id from the nyc_legislation archive
+//
+// A nil result (with no error) is returned when no archive is configured or id is not archived
+func (a *App) getArchivedLegislation(ctx context.Context, id IntroID) (*Legislation, error) {
+	if a.archive == nil {
+		return nil, nil
+	}
+	rc, _, err := a.archive.Get(ctx, archivePath(id))
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+	var l Legislation
+	err = json.NewDecoder(rc).Decode(&l)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// getMatterID returns the Legistar Matter ID for id from the archive, falling
+// back to the Legistar API when not in offline mode.
+//
+// A matter ID of 0 indicates id was not found.
+func (a *App) getMatterID(ctx context.Context, id IntroID) (int, error) {
+	l, err := a.getArchivedLegislation(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if l != nil {
+		return l.ID, nil
+	}
+	if a.offline {
+		return 0, nil
+	}
+	m, err := a.lookupMatter(ctx, id)
+	if err != nil || m == nil {
+		return 0, err
+	}
+	return m.ID, nil
+}
+
+// getAttachments returns the Legistar Matter ID and attachments for id
+// from the archive, falling back to the Legistar API when not in offline mode.
+//
+// A matter ID of 0 indicates id was not found.
+func (a *App) getAttachments(ctx context.Context, id IntroID) (int, []db.Attachment, error) {
+	l, err := a.getArchivedLegislation(ctx, id)
+	if err != nil {
+		return 0, nil, err
+	}
+	if l != nil {
+		return l.ID, l.Attachments, nil
+	}
+	if a.offline {
+		return 0, nil, nil
+	}
+	m, err := a.lookupMatter(ctx, id)
+	if err != nil || m == nil {
+		return 0, nil, err
+	}
+	attachments, err := a.legistar.MatterAttachments(ctx, m.ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	var o []db.Attachment
+	for _, attachment := range attachments {
+		o = append(o, db.NewAttachment(attachment))
+	}
+	return m.ID, o, nil
+}
+
+// lookupMatter finds the Matter for id with the Legistar API; nil if not found
+func (a *App) lookupMatter(ctx context.Context, id IntroID) (*legistar.Matter, error) {
+	filter := legistar.AndFilters(
+		legistar.MatterTypeFilter(id.Type()),
+		legistar.MatterFileFilter(id.File()),
+	)
+
+	// TODO: retry with a suffix -A for older years
+	// i.e. Int 0804-1996-A
+	matters, err := a.legistar.Matters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(matters) != 1 {
+		return nil, nil
+	}
+	return &matters[0], nil
+}
+
+// legistarWebURL returns the URL to a Matter on legistar.council.nyc.gov
+//
+// In offline mode the gateway URL (which redirects to the detail page) is
+// returned instead of being resolved with a request.
+func (a *App) legistarWebURL(ctx context.Context, matterID int) (string, error) {
+	if a.offline {
+		return a.legistar.LookupURL.String() + strconv.Itoa(matterID), nil
+	}
+	return a.legistar.LookupWebURL(ctx, matterID)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestArchivePath(t *testing.T) {
+	for have, expect := range map[IntroID]string{
+		"0012-2024":     "introduction/2024/0012.json",
+		"res-0707-2025": "resolution/2025/0707.json",
+	} {
+		if got := archivePath(have); got != expect {
+			t.Errorf("archivePath(%q) = %q, want %q", have, got, expect)
+		}
+	}
+}
+
+func TestGetLegislationOffline(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApp(t, nil)
+	archive := NewMemoryStore()
+	archive.Put(ctx, "introduction/2024/0012.json", "", strings.NewReader(`{"ID":123,"File":"Int 0012-2024","Name":"Test","Attachments":[{"Name":"Local Law 1","Link":"https://example.com/ll.pdf"}]}`))
+	app.archive = archive
+	app.offline = true
+
+	l, err := app.GetLegislation(ctx, "0012-2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l == nil || l.ID != 123 || l.Name != "Test" {
+		t.Fatalf("got %#v", l)
+	}
+	matterID, attachments, err := app.getAttachments(ctx, "0012-2024")
+	if err != nil || matterID != 123 || len(attachments) != 1 {
+		t.Errorf("getAttachments = %d, %v, %v", matterID, attachments, err)
+	}
+
+	l, err = app.GetLegislation(ctx, "0013-2024")
+	if err != nil || l != nil {
+		t.Errorf("missing legislation got %#v, %v", l, err)
+	}
+}
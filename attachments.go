@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/jehiah/legislator/db"
+)
+
+// BillAttachment is a Legistar attachment on legislation with a stable intro.nyc URL
+type BillAttachment struct {
+	ID           int
+	Name         string // i.e. "Committee Report 2/26/24"
+	Type         string // i.e. "Committee Report", "Hearing Testimony"
+	Slug         string // i.e. "committee-report-2-26-24-12345"
+	URL          string // i.e. "/1234-2024/attachments/committee-report-2-26-24-12345"
+	LegistarLink string
+	LastModified time.Time
+}
+
+// attachmentTypes are checked in order against the lowercase attachment name
+var attachmentTypes = []struct {
+	Type     string
+	Prefixes []string
+	Contains []string
+}{
+	{"Committee Report", []string{"committee report"}, nil},
+	{"Fiscal Impact Statement", []string{"fiscal impact"}, []string{"fiscal impact statement"}},
+	{"Hearing Testimony", nil, []string{"testimony"}},
+	{"Hearing Transcript", []string{"transcript"}, []string{"hearing transcript"}},
+	{"Local Law", []string{"local law"}, nil},
+	{"Memo", nil, []string{"memo"}}, // i.e. "Memo in Support", "Legislative Memorandum"
+}
+
+// classifyAttachment returns the type of an attachment from its name; "Other" if unknown
+func classifyAttachment(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, t := range attachmentTypes {
+		for _, p := range t.Prefixes {
+			if strings.HasPrefix(name, p) {
+				return t.Type
+			}
+		}
+		for _, c := range t.Contains {
+			if strings.Contains(name, c) {
+				return t.Type
+			}
+		}
+	}
+	return "Other"
+}
+
+// newBillAttachments classifies attachments on id and assigns each a slug from its name and
+// Legistar attachment ID (i.e. committee-report-2-26-24-12345) so a renamed attachment can still
+// be found by ID (See findBillAttachment)
+func newBillAttachments(id IntroID, attachments []db.Attachment) []BillAttachment {
+	o := []BillAttachment{}
+	for _, a := range attachments {
+		s := strings.TrimPrefix(slug.Make(a.Name)+"-"+strconv.Itoa(a.ID), "-")
+		o = append(o, BillAttachment{
+			ID:           a.ID,
+			Name:         strings.TrimSpace(a.Name),
+			Type:         classifyAttachment(a.Name),
+			Slug:         s,
+			URL:          fmt.Sprintf("/%s/attachments/%s", id, s),
+			LegistarLink: a.Link,
+			LastModified: a.LastModified,
+		})
+	}
+	return o
+}
+
+// findBillAttachment returns the attachment for key; nil if not found.
+//
+// Besides the current slug, key can be the slug of the name without an ID (i.e. committee-report-2-26-24)
+// or end in the attachment ID with an older name (i.e. committee-report-2-26-23-12345), in which case
+// current is false.
+func findBillAttachment(attachments []BillAttachment, key string) (attachment *BillAttachment, current bool) {
+	for i := range attachments {
+		if attachments[i].Slug == key {
+			return &attachments[i], true
+		}
+	}
+	// the slug of a name ends in a number for dates (i.e. -24) so check names first
+	for i := range attachments {
+		if slug.Make(attachments[i].Name) == key {
+			return &attachments[i], false
+		}
+	}
+	if n, err := strconv.Atoi(key[strings.LastIndex(key, "-")+1:]); err == nil {
+		for i := range attachments {
+			if attachments[i].ID == n {
+				return &attachments[i], false
+			}
+		}
+	}
+	return nil, false
+}
+
+// BillAttachments returns the classified attachments with intro.nyc URLs
+func (ll Legislation) BillAttachments() []BillAttachment {
+	return newBillAttachments(ll.IntroID(), ll.Attachments)
+}
+
+// attachmentStorePath is the location of a cached attachment in the store (next to local_laws/)
+// i.e. attachments/1234-2024/committee-report-2-26-24-12345.pdf
+func attachmentStorePath(id IntroID, s string) string {
+	return path.Join("attachments", string(id), s+".pdf")
+}
+
+// getBillAttachments returns the classified attachments for id; nil if id is not found
+func (a *App) getBillAttachments(ctx context.Context, id IntroID) ([]BillAttachment, error) {
+	matterID, attachments, err := a.getAttachments(ctx, id)
+	if err != nil || matterID == 0 {
+		return nil, err
+	}
+	return newBillAttachments(id, attachments), nil
+}
+
+// Attachments lists the classified attachments of legislation
+// URL: /1234-2020/attachments.json
+func (a *App) Attachments(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseIntroID(r.PathValue("file"))
+	if err != nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	attachments, err := a.getBillAttachments(r.Context(), id)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "unknown error", 500)
+		return
+	}
+	if attachments == nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	a.addExpireHeaders(w, time.Hour)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// Attachment serves an attachment from the store, downloading it from Legistar the first time
+// (and when Legistar reports a newer version) so links survive changes to Legistar.
+// URL: /1234-2020/attachments/committee-report-2-26-24-12345
+//
+// Only PDFs are cached; other attachments redirect to Legistar.
+func (a *App) Attachment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := ParseIntroID(r.PathValue("file"))
+	if err != nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	key := strings.TrimSuffix(r.PathValue("attachment"), ".pdf")
+	if !slug.IsSlug(key) {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	filename := fmt.Sprintf("%s-%s.pdf", id, key)
+	storefile := attachmentStorePath(id, key)
+
+	// a cached copy is still served when Legistar is unavailable
+	attachments, lookupErr := a.getBillAttachments(ctx, id)
+	if lookupErr != nil {
+		log.Print(lookupErr)
+	}
+	attachment, current := findBillAttachment(attachments, key)
+	if lookupErr == nil && attachment == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	if attachment != nil && !current {
+		a.addExpireHeaders(w, time.Hour)
+		http.Redirect(w, r, attachment.URL, 301)
+		return
+	}
+
+	// first check the store
+	pdfReader, attrs, err := a.store.Get(ctx, storefile)
+	if err != nil && !isNotExist(err) {
+		log.Print(err)
+	} else if err == nil {
+		defer pdfReader.Close()
+		if attachment == nil || !attachment.LastModified.After(attrs.LastModified) {
+			// handle 304
+			if r.Header.Get("if-modified-since") == attrs.LastModified.Format(http.TimeFormat) {
+				w.WriteHeader(304)
+				return
+			}
+			w.Header().Set("content-type", "application/pdf")
+			a.addExpireHeaders(w, time.Hour*24*7)
+			w.Header().Set("content-disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+			w.Header().Set("content-length", fmt.Sprintf("%d", attrs.Size))
+			w.Header().Set("last-modified", attrs.LastModified.Format(http.TimeFormat))
+			io.Copy(w, pdfReader)
+			return
+		}
+		log.Printf("%s updated %s", storefile, attachment.LastModified)
+	}
+
+	if lookupErr != nil {
+		http.Error(w, "unknown error", 500)
+		return
+	}
+
+	// fetch it and cache it
+	body, err := downloadAttachment(ctx, attachment.LegistarLink)
+	if errors.Is(err, errNotPDF) {
+		a.addExpireHeaders(w, time.Hour)
+		http.Redirect(w, r, attachment.LegistarLink, 302)
+		return
+	} else if err != nil {
+		log.Print(err)
+		http.Error(w, "unknown error", 500)
+		return
+	}
+	defer body.Close()
+	// copy body to the store and w
+	w.Header().Set("content-type", "application/pdf")
+	a.addExpireHeaders(w, time.Hour*24*7)
+	w.Header().Set("content-disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	err = a.store.Put(ctx, storefile, "application/pdf", io.TeeReader(body, w))
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+var errNotPDF = errors.New("attachment is not a PDF")
+
+// downloadAttachment returns the body of a PDF attachment from Legistar; errNotPDF for other content types
+func downloadAttachment(ctx context.Context, link string) (io.ReadCloser, error) {
+	log.Printf("downloading %s", link)
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status code %d for %s", resp.StatusCode, link)
+	}
+	if t, _, _ := mime.ParseMediaType(resp.Header.Get("content-type")); t != "application/pdf" {
+		resp.Body.Close()
+		return nil, errNotPDF
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/ledongthuc/pdf"
+)
+
+// searchableAttachmentTypes are the attachment types included in build/search_attachments_*.json
+var searchableAttachmentTypes = []string{"Committee Report", "Hearing Testimony"}
+
+// maxAttachmentText limits the text indexed from a single attachment
+const maxAttachmentText = 1 << 20
+
+// attachmentExcerptWords is the length of the excerpt kept for search snippets
+const attachmentExcerptWords = 60
+
+// searchAttachmentsFile returns the build file for the attachment search index of a session
+// introType is "introduction" or "resolution"
+func searchAttachmentsFile(s Session, introType string) string {
+	if introType == "resolution" {
+		return fmt.Sprintf("build/search_attachments_resolution_%s.json", s)
+	}
+	return fmt.Sprintf("build/search_attachments_%s.json", s)
+}
+
+// attachmentTextStorePath is the location of the text extracted from a cached attachment
+// i.e. attachments/1234-2024/committee-report-2-26-24-12345.txt
+func attachmentTextStorePath(id IntroID, s string) string {
+	return path.Join("attachments", string(id), s+".txt")
+}
+
+// AttachmentTypes returns the attachment types for an "attachments" parameter of all or
+// comma separated slugs (i.e. hearing-testimony,committee-report)
+func AttachmentTypes(v string) (map[string]bool, error) {
+	o := make(map[string]bool)
+	for _, s := range strings.Split(v, ",") {
+		var found bool
+		for _, t := range searchableAttachmentTypes {
+			if s == "all" || s == slug.Make(t) {
+				o[t], found = true, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown attachment type %q", s)
+		}
+	}
+	return o, nil
+}
+
+// extractPDFText returns the text of a PDF with whitespace collapsed, truncated to maxAttachmentText
+func extractPDFText(r io.ReaderAt, size int64) (text string, err error) {
+	defer func() {
+		// the pdf package panics on some malformed files
+		if p := recover(); p != nil {
+			err = fmt.Errorf("extracting pdf text: %v", p)
+		}
+	}()
+	doc, err := pdf.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+	plain, err := doc.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(plain)
+	if err != nil {
+		return "", err
+	}
+	text = strings.Join(strings.Fields(string(body)), " ")
+	if len(text) > maxAttachmentText {
+		text = text[:maxAttachmentText]
+		if i := strings.LastIndex(text, " "); i > 0 {
+			text = text[:i]
+		}
+	}
+	return text, nil
+}
+
+// AttachmentSearchRow is a row in build/search_attachments_$session.json (See SearchEntry)
+type AttachmentSearchRow struct {
+	File, Name, StatusName string
+	Attachment             string // i.e. "Hearing Testimony 2/26/24"
+	AttachmentType         string
+	URL                    string // i.e. /1234-2024/attachments/hearing-testimony-2-26-24-12345
+	LastModified           time.Time
+	Terms                  []string // the distinct words of the text (See tokenize)
+	Excerpt                string   // the start of the text
+}
+
+// attachmentTerms returns the distinct words in text (sorted) excluding stop words
+func attachmentTerms(text string) []string {
+	seen := make(map[string]bool)
+	var o []string
+	for _, t := range tokenize(text) {
+		if stopWords[t] || seen[t] {
+			continue
+		}
+		seen[t] = true
+		o = append(o, t)
+	}
+	sort.Strings(o)
+	return o
+}
+
+// attachmentExcerpt returns the first attachmentExcerptWords words of text
+func attachmentExcerpt(text string) string {
+	words := strings.Fields(text)
+	return strings.Join(words[:min(len(words), attachmentExcerptWords)], " ")
+}
+
+// BuildAttachments writes build/search_attachments_$session.json with the terms (and an excerpt) of committee
+// reports and hearing testimony for sessions.
+//
+// Attachments are downloaded from Legistar into the store (attachments/$file/$slug.pdf) when not
+// already cached, and the extracted text is kept next to them (attachments/$file/$slug.txt).
+// Attachments that can't be downloaded or read are skipped.
+func (x *Indexer) BuildAttachments(ctx context.Context, sessions []Session) error {
+	if x.Now.IsZero() {
+		x.Now = time.Now()
+	}
+	searchable := make(map[string]bool)
+	for _, t := range searchableAttachmentTypes {
+		searchable[t] = true
+	}
+	for _, s := range sessions {
+		for _, introType := range []string{"introduction", "resolution"} {
+			var rows []AttachmentSearchRow
+			for _, year := range x.years(s) {
+				legislation, err := x.readLegislation(introType, year)
+				if err != nil {
+					return err
+				}
+				for _, l := range legislation {
+					id, err := ParseFile(l.File)
+					if err != nil {
+						continue
+					}
+					for _, a := range newBillAttachments(id, l.Attachments) {
+						if !searchable[a.Type] {
+							continue
+						}
+						text, err := x.attachmentText(ctx, id, a)
+						if err != nil {
+							log.Printf("%s %s: %s", l.File, a.Name, err)
+							continue
+						}
+						if text == "" {
+							continue
+						}
+						rows = append(rows, AttachmentSearchRow{
+							File:           l.File,
+							Name:           l.Name,
+							StatusName:     l.StatusName,
+							Attachment:     a.Name,
+							AttachmentType: a.Type,
+							URL:            a.URL,
+							LastModified:   a.LastModified,
+							Terms:          attachmentTerms(text),
+							Excerpt:        attachmentExcerpt(text),
+						})
+					}
+				}
+			}
+			if len(rows) == 0 {
+				continue
+			}
+			log.Printf("building %s", searchAttachmentsFile(s, introType))
+			if err := x.put(ctx, searchAttachmentsFile(s, introType), rows); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// attachmentText returns the (cached) text of attachment a, downloading the PDF when it's not in the
+// store or Legistar has a newer version
+func (x *Indexer) attachmentText(ctx context.Context, id IntroID, a BillAttachment) (string, error) {
+	textfile := attachmentTextStorePath(id, a.Slug)
+	rc, attrs, err := x.Store.Get(ctx, textfile)
+	if err != nil && !isNotExist(err) {
+		return "", err
+	} else if err == nil {
+		defer rc.Close()
+		if !a.LastModified.After(attrs.LastModified) {
+			body, err := io.ReadAll(rc)
+			return string(body), err
+		}
+	}
+
+	storefile := attachmentStorePath(id, a.Slug)
+	attrs, err = x.Store.Stat(ctx, storefile)
+	if err != nil && !isNotExist(err) {
+		return "", err
+	}
+	if err != nil || a.LastModified.After(attrs.LastModified) {
+		body, err := downloadAttachment(ctx, a.LegistarLink)
+		if errors.Is(err, errNotPDF) {
+			// cache empty text so it's not downloaded again until Legistar has a newer version
+			return "", x.Store.Put(ctx, textfile, "text/plain; charset=utf-8", strings.NewReader(""))
+		} else if err != nil {
+			return "", err
+		}
+		err = x.Store.Put(ctx, storefile, "application/pdf", body)
+		body.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	rc, _, err = x.Store.Get(ctx, storefile)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	text, err := extractPDFText(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return "", err
+	}
+	log.Printf("extracted %d bytes of text from %s", len(text), storefile)
+	return text, x.Store.Put(ctx, textfile, "text/plain; charset=utf-8", strings.NewReader(text))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// testPDF returns a single page PDF with text
+func testPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	var offsets []int
+	for i, o := range objects {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	body := testPDF("Testimony in support of e-bikes")
+	text, err := extractPDFText(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Testimony in support of e-bikes" {
+		t.Errorf("got %q", text)
+	}
+
+	if _, err := extractPDFText(strings.NewReader("not a pdf"), 9); err == nil {
+		t.Errorf("expected error for invalid pdf")
+	}
+}
+
+func TestAttachmentTypes(t *testing.T) {
+	if got, err := AttachmentTypes("all"); err != nil || len(got) != 2 {
+		t.Errorf("all got %v %v", got, err)
+	}
+	if got, err := AttachmentTypes("hearing-testimony"); err != nil || !got["Hearing Testimony"] || got["Committee Report"] {
+		t.Errorf("hearing-testimony got %v %v", got, err)
+	}
+	if _, err := AttachmentTypes("memo"); err == nil {
+		t.Errorf("expected error for memo")
+	}
+}
+
+func TestAttachmentTermsAndExcerpt(t *testing.T) {
+	text := strings.Repeat("Testimony of the Transportation Alternatives ", 20) + "in support of helmets"
+	if got := strings.Join(attachmentTerms(text), " "); got != "alternatives helmets support testimony transportation" {
+		t.Errorf("got terms %q", got)
+	}
+	excerpt := attachmentExcerpt(text)
+	if n := len(strings.Fields(excerpt)); n != attachmentExcerptWords || strings.Contains(excerpt, "helmets") {
+		t.Errorf("got %d words %q", n, excerpt)
+	}
+
+	// terms past the excerpt match, with a snippet from the start of the excerpt
+	idx := NewSearchIndex([]SearchEntry{{File: "Int 0001-2024", Name: "Bike Lanes", Terms: attachmentTerms(text), Excerpt: excerpt}})
+	results := idx.Search("helmets", nil)
+	if len(results) != 1 || !strings.HasPrefix(results[0].Snippet, "Testimony of the") || strings.Contains(results[0].Snippet, "<mark>") {
+		t.Errorf("got %#v", results)
+	}
+}
+
+func TestIndexerBuildAttachments(t *testing.T) {
+	ctx := context.Background()
+	year := CurrentSession.StartYear
+	var downloads int
+	legistar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Header().Set("content-type", "application/pdf")
+		switch r.URL.Path {
+		case "/testimony.pdf":
+			w.Write(testPDF("Delivery workers testified about e-bikes and battery safety"))
+		case "/report.pdf":
+			w.Write(testPDF("The Committee on Transportation will consider bike lanes"))
+		case "/report.docx":
+			w.Header().Set("content-type", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+		default:
+			w.Write(testPDF("Fiscal impact"))
+		}
+	}))
+	defer legistar.Close()
+
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	source := fstest.MapFS{
+		fmt.Sprintf("introduction/%d/0001.json", year): file(fmt.Sprintf(`{"File":"Int 0001-%d","Name":"E-Bike Safety","StatusName":"Committee","Attachments":[
+			{"ID":1,"Name":"Hearing Testimony 3/1/%d","Link":"%s/testimony.pdf","LastModified":"%d-03-01T00:00:00Z"},
+			{"ID":2,"Name":"Committee Report 3/1/%d","Link":"%s/report.pdf","LastModified":"%d-03-01T00:00:00Z"},
+			{"ID":3,"Name":"Fiscal Impact Statement","Link":"%s/fis.pdf","LastModified":"%d-03-01T00:00:00Z"},
+			{"ID":4,"Name":"Committee Report","Link":"%s/report.docx","LastModified":"%d-03-01T00:00:00Z"}]}`,
+			year, year%100, legistar.URL, year, year%100, legistar.URL, year, legistar.URL, year, legistar.URL, year)),
+	}
+	store := NewMemoryStore()
+	x := &Indexer{Source: source, Store: store, Now: time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC)}
+	for i := 0; i < 2; i++ {
+		if err := x.BuildAttachments(ctx, []Session{CurrentSession}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if downloads != 3 {
+		t.Errorf("got %d downloads, expected 3 (fiscal impact statements are not indexed and non-PDFs are downloaded once)", downloads)
+	}
+	id := IntroID(fmt.Sprintf("0001-%d", year))
+	slug := fmt.Sprintf("hearing-testimony-3-1-%d-1", year%100)
+	for _, f := range []string{attachmentStorePath(id, slug), attachmentTextStorePath(id, slug)} {
+		if _, err := store.Stat(ctx, f); err != nil {
+			t.Errorf("%s %s", f, err)
+		}
+	}
+
+	app := newTestApp(t, nil)
+	app.store = store
+	search := func(qs string) (int, []SearchResult) {
+		w := httptest.NewRecorder()
+		app.SearchAPI(w, httptest.NewRequest("GET", "/api/search?"+qs, nil))
+		var resp struct{ Results []SearchResult }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Results
+	}
+
+	code, results := search("q=e-bikes&attachments=hearing-testimony")
+	if code != 200 || len(results) != 1 {
+		t.Fatalf("got status %d %#v", code, results)
+	}
+	r := results[0]
+	if r.URL != "https://intro.nyc/"+string(id)+"/attachments/"+slug || r.AttachmentType != "Hearing Testimony" || r.Name != "E-Bike Safety" {
+		t.Errorf("got %#v", r)
+	}
+	if !strings.Contains(r.Snippet, "<mark>e-bikes</mark>") {
+		t.Errorf("got snippet %q", r.Snippet)
+	}
+
+	if _, results := search("q=bike+lanes&attachments=hearing-testimony"); len(results) != 0 {
+		t.Errorf("expected committee report to be filtered %#v", results)
+	}
+	if _, results := search("q=bike+lanes&attachments=all"); len(results) != 1 || results[0].AttachmentType != "Committee Report" {
+		t.Errorf("got %#v", results)
+	}
+	if code, _ := search("q=bike&attachments=memo"); code != 400 {
+		t.Errorf("unknown attachments got status %d", code)
+	}
+}
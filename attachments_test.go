@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jehiah/legislator/db"
+)
+
+func TestClassifyAttachment(t *testing.T) {
+	for name, expect := range map[string]string{
+		"Committee Report 2/26/24":           "Committee Report",
+		"Committee Report - Stated Meeting":  "Committee Report",
+		"Fiscal Impact Statement":            "Fiscal Impact Statement",
+		"Hearing Testimony 2/26/24":          "Hearing Testimony",
+		"Hearing Transcript 2/26/24":         "Hearing Transcript",
+		"Local Law 12":                       "Local Law",
+		"Memo in Support":                    "Memo",
+		"Legislative Documents - Memorandum": "Memo",
+		"Summary of Int. No. 12":             "Other",
+	} {
+		if got := classifyAttachment(name); got != expect {
+			t.Errorf("classifyAttachment(%q) = %q, want %q", name, got, expect)
+		}
+	}
+}
+
+func TestNewBillAttachments(t *testing.T) {
+	attachments := newBillAttachments("0012-2024", []db.Attachment{
+		{ID: 1, Name: "Committee Report 2/26/24"},
+		{ID: 3, Name: "Hearing Testimony"},
+		{ID: 5, Name: "???"},
+		{ID: 7, Name: "Hearing Testimony"},
+	})
+	var got []string
+	for _, a := range attachments {
+		got = append(got, a.URL)
+	}
+	expect := []string{
+		"/0012-2024/attachments/committee-report-2-26-24-1",
+		"/0012-2024/attachments/hearing-testimony-3",
+		"/0012-2024/attachments/5",
+		"/0012-2024/attachments/hearing-testimony-7",
+	}
+	if strings.Join(got, " ") != strings.Join(expect, " ") {
+		t.Errorf("got %v, want %v", got, expect)
+	}
+
+	for key, expect := range map[string]struct {
+		ID      int
+		Current bool
+	}{
+		"committee-report-2-26-24-1": {1, true},
+		"committee-report-2-26-23-1": {1, false}, // renamed
+		"committee-report-2-26-24":   {1, false}, // without an ID
+		"hearing-testimony":          {3, false},
+		"hearing-testimony-7":        {7, true},
+		"fiscal-impact-statement-9":  {},
+		"missing":                    {},
+	} {
+		a, current := findBillAttachment(attachments, key)
+		if expect.ID == 0 {
+			if a != nil {
+				t.Errorf("%q got %#v", key, a)
+			}
+			continue
+		}
+		if a == nil || a.ID != expect.ID || current != expect.Current {
+			t.Errorf("%q got %#v %v", key, a, current)
+		}
+	}
+}
+
+func TestAttachment(t *testing.T) {
+	ctx := context.Background()
+	var downloads int
+	legistar := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		if r.URL.Path == "/memo.docx" {
+			w.Header().Set("content-type", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+			return
+		}
+		w.Header().Set("content-type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.4 committee report")
+	}))
+	defer legistar.Close()
+
+	app := newTestApp(t, nil)
+	archive := NewMemoryStore()
+	archive.Put(ctx, "introduction/2024/0012.json", "", strings.NewReader(`{"ID":123,"File":"Int 0012-2024","Name":"Test","Attachments":[
+		{"ID":1,"Name":"Committee Report 2/26/24","Link":"`+legistar.URL+`/report.pdf","LastModified":"2024-02-26T10:00:00Z"},
+		{"ID":2,"Name":"Memo in Support","Link":"`+legistar.URL+`/memo.docx","LastModified":"2024-02-26T10:00:00Z"}]}`))
+	app.archive = archive
+	app.offline = true
+
+	get := func(file, attachment string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/"+file+"/attachments/"+attachment, nil)
+		r.SetPathValue("file", file)
+		r.SetPathValue("attachment", attachment)
+		app.Attachment(w, r)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		w := get("0012-2024", "committee-report-2-26-24-1")
+		if w.Code != 200 || w.Body.String() != "%PDF-1.4 committee report" {
+			t.Fatalf("got status %d %q", w.Code, w.Body)
+		}
+		if ct := w.Header().Get("content-type"); ct != "application/pdf" {
+			t.Errorf("got content-type %q", ct)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("got %d downloads, expected 1", downloads)
+	}
+	if _, err := app.store.Stat(ctx, "attachments/0012-2024/committee-report-2-26-24-1.pdf"); err != nil {
+		t.Errorf("attachment not cached %s", err)
+	}
+
+	if w := get("0012-2024", "memo-in-support-2"); w.Code != 302 || w.Header().Get("location") != legistar.URL+"/memo.docx" {
+		t.Errorf("memo got status %d location %q", w.Code, w.Header().Get("location"))
+	}
+
+	// links to a name-only or renamed slug redirect to the current one
+	for _, key := range []string{"committee-report-2-26-24", "committee-report-2-26-23-1"} {
+		w := get("0012-2024", key)
+		if w.Code != 301 || w.Header().Get("location") != "/0012-2024/attachments/committee-report-2-26-24-1" {
+			t.Errorf("%q got status %d location %q", key, w.Code, w.Header().Get("location"))
+		}
+	}
+
+	// served from the store once Legistar is gone
+	legistar.Close()
+	if w := get("0012-2024", "committee-report-2-26-24-1"); w.Code != 200 {
+		t.Errorf("got status %d after Legistar closed", w.Code)
+	}
+
+	// only known slugs are read from the store
+	app.store.Put(ctx, "attachments/0012-2024/missing.pdf", "application/pdf", strings.NewReader("%PDF-1.4 other"))
+	app.store.Put(ctx, "attachments/0013-2024/committee-report-2-26-24-1.pdf", "application/pdf", strings.NewReader("%PDF-1.4 other"))
+	for _, key := range []string{"missing", "../0013-2024/committee-report-2-26-24-1", "Committee-Report-2-26-24-1"} {
+		if w := get("0012-2024", key); w.Code != 404 {
+			t.Errorf("%q got status %d", key, w.Code)
+		}
+	}
+	if w := get("0013-2024", "committee-report-2-26-24-1"); w.Code != 404 {
+		t.Errorf("missing legislation got status %d", w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/0012-2024/attachments.json", nil)
+	r.SetPathValue("file", "0012-2024")
+	app.Attachments(w, r)
+	var attachments []BillAttachment
+	if err := json.Unmarshal(w.Body.Bytes(), &attachments); err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 2 || attachments[0].Type != "Committee Report" || attachments[1].Type != "Memo" {
+		t.Errorf("got %#v", attachments)
+	}
+}
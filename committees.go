@@ -0,0 +1,397 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/jehiah/legislator/db"
+)
+
+// Committee is a council committee (or subcommittee) for a session (/committees/$slug.json)
+type Committee struct {
+	BodyName string // i.e. "Committee on Transportation and Infrastructure"
+	Slug     string // i.e. "transportation-and-infrastructure"
+	Session  string
+	Members  []CommitteeMember // chair(s) first
+	Referred []CommitteeBill   // legislation currently referred to the committee
+	VotedOut []CommitteeBill   // legislation approved by the committee
+	Hearings []CommitteeEvent  // past meetings (newest first)
+	Upcoming []CommitteeEvent
+}
+
+// CommitteeMember is a council member on a committee along with their attendance at
+// the committee's roll calls
+type CommitteeMember struct {
+	Councilmember    db.PersonReference
+	Party            string
+	Title            string // i.e. "CHAIRPERSON", "Committee Member"
+	Chair            bool
+	Start, End       time.Time
+	ExpectedRollCall int
+	RollCall         int
+	RollCallPercent  float64 // 0-100
+}
+
+type CommitteeBill struct {
+	File           string
+	Name           string
+	StatusName     string
+	IntroDate      time.Time
+	PrimarySponsor db.PersonReference
+	Sponsors       int
+	Date           *time.Time `json:",omitempty"` // date approved by the committee
+}
+
+type CommitteeEvent struct {
+	ID               int
+	Date             time.Time
+	Location         string
+	AgendaStatusName string
+	InSiteURL        string
+	Items            int // agenda items
+	Legislation      []CommitteeEventItem
+}
+
+// Link returns the path of the event page i.e. /events/2024/1234
+func (e CommitteeEvent) Link() string {
+	return eventLink(e.Date, e.ID)
+}
+
+// CommitteeEventItem is legislation on the agenda of a committee meeting
+type CommitteeEventItem struct {
+	File       string
+	Name       string
+	ActionName string `json:",omitempty"` // i.e. "Hearing Held by Committee"
+}
+
+func (c Committee) Name() string {
+	return TrimCommittee(c.BodyName)
+}
+
+// Chairs returns the members who chair the committee
+func (c Committee) Chairs() []CommitteeMember {
+	var o []CommitteeMember
+	for _, m := range c.Members {
+		if m.Chair {
+			o = append(o, m)
+		}
+	}
+	return o
+}
+
+func (b CommitteeBill) IntroLink() string {
+	id, _ := ParseFile(b.File)
+	return "/" + string(id)
+}
+
+func (i CommitteeEventItem) IntroLink() string {
+	id, _ := ParseFile(i.File)
+	return "/" + string(id)
+}
+
+// isCommittee returns true for office records (and events) for a committee or subcommittee
+func isCommittee(bodyName string) bool {
+	switch {
+	case bodyName == "Committee of the Whole":
+		return false
+	case strings.HasPrefix(bodyName, "Committee"), strings.HasPrefix(bodyName, "Subcommittee"):
+		return true
+	}
+	return false
+}
+
+// getCommittees builds each committee with members during session s from build/people_all.json,
+// the legislation in the session from build/$year.json and events from build/events_$year.json
+// (attendance from build/events_attendance_$year.json)
+//
+// Committees are sorted by name.
+func (a *App) getCommittees(ctx context.Context, s Session) ([]Committee, error) {
+	var people []db.Person
+	err := a.getJSONFile(ctx, "build/people_all.json", &people)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	committees := make(map[string]*Committee)
+	members := make(map[string]map[int]*CommitteeMember)
+	for _, p := range people {
+		for _, or := range p.OfficeRecords {
+			if !isCommittee(or.BodyName) || !s.Overlaps(or.Start, or.End) {
+				continue
+			}
+			if s.IsCurrent() && or.End.Before(now) {
+				continue // only current members
+			}
+			key := slug.Make(TrimCommittee(or.BodyName))
+			if committees[key] == nil {
+				committees[key] = &Committee{BodyName: or.BodyName, Slug: key, Session: s.String()}
+				members[key] = make(map[int]*CommitteeMember)
+			}
+			if m, ok := members[key][p.ID]; ok {
+				// i.e. a member who becomes chair
+				if or.End.After(m.End) {
+					m.Title, m.Chair, m.End = or.Title, or.MemberType == "CHAIR" || or.Title == "CHAIRPERSON", or.End
+				}
+				continue
+			}
+			members[key][p.ID] = &CommitteeMember{
+				Councilmember: db.PersonReference{ID: p.ID, Slug: p.Slug, FullName: p.FullName},
+				Party:         Person{Person: p}.PartyShort(),
+				Title:         or.Title,
+				Chair:         or.MemberType == "CHAIR" || or.Title == "CHAIRPERSON",
+				Start:         or.Start,
+				End:           or.End,
+			}
+		}
+	}
+
+	legislation, err := a.getSessionLegislation(ctx, s, []string{"introduction", "resolution"}, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range legislation {
+		if l.StatusName == "Withdrawn" {
+			continue
+		}
+		bill := CommitteeBill{
+			File:           l.File,
+			Name:           l.Name,
+			StatusName:     l.StatusName,
+			IntroDate:      l.IntroDate,
+			PrimarySponsor: l.PrimarySponsor(),
+			Sponsors:       len(l.Sponsors),
+		}
+		if c, ok := committees[slug.Make(TrimCommittee(l.BodyName))]; ok {
+			c.Referred = append(c.Referred, bill)
+		}
+		seen := make(map[string]bool)
+		for _, h := range l.History {
+			if h.Action != "Approved by Committee" || seen[h.BodyName] {
+				continue
+			}
+			seen[h.BodyName] = true
+			if c, ok := committees[slug.Make(TrimCommittee(h.BodyName))]; ok {
+				b := bill
+				b.Date = &h.Date
+				c.VotedOut = append(c.VotedOut, b)
+			}
+		}
+	}
+
+	events, err := a.getEvents(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	today := now.In(americaNewYork).Truncate(time.Hour * 24)
+	for _, e := range events {
+		c, ok := committees[slug.Make(TrimCommittee(e.BodyName))]
+		if !ok {
+			continue
+		}
+		ce := CommitteeEvent{
+			ID:               e.ID,
+			Date:             e.Date,
+			Location:         e.Location,
+			AgendaStatusName: e.AgendaStatusName,
+			InSiteURL:        e.InSiteURL,
+			Items:            len(e.Items),
+			Legislation:      []CommitteeEventItem{},
+		}
+		for _, i := range e.Items {
+			switch i.MatterType {
+			case "Introduction", "Resolution":
+				if !i.IsDraft() {
+					ce.Legislation = append(ce.Legislation, CommitteeEventItem{File: i.MatterFile, Name: i.MatterName, ActionName: i.ActionName})
+				}
+			}
+		}
+		switch {
+		case !e.Date.Before(today):
+			c.Upcoming = append(c.Upcoming, ce)
+		case e.AgendaStatusName == "Deferred":
+		default:
+			c.Hearings = append(c.Hearings, ce)
+		}
+	}
+
+	// attendance at the committee roll calls
+	for year := s.StartYear; year <= s.EndYear && year <= now.Year(); year++ {
+		var events []db.Event
+		err := a.getJSONFile(ctx, fmt.Sprintf("build/events_attendance_%d.json", year), &events)
+		if err != nil {
+			if isNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, e := range events {
+			key := slug.Make(TrimCommittee(e.BodyName))
+			if _, ok := committees[key]; !ok {
+				continue
+			}
+			for _, i := range e.Items {
+				for _, rollcall := range i.RollCall {
+					m, ok := members[key][rollcall.ID]
+					if !ok || rollCallExcused(rollcall.ValueID) {
+						continue
+					}
+					m.ExpectedRollCall++
+					if rollcall.ValueID == 13 {
+						m.RollCall++
+					}
+				}
+			}
+		}
+	}
+
+	var o []Committee
+	for key, c := range committees {
+		for _, m := range members[key] {
+			if m.ExpectedRollCall > 0 {
+				m.RollCallPercent = (float64(m.RollCall) / float64(m.ExpectedRollCall)) * 100
+			}
+			c.Members = append(c.Members, *m)
+		}
+		sort.Slice(c.Members, func(i, j int) bool {
+			if c.Members[i].Chair != c.Members[j].Chair {
+				return c.Members[i].Chair
+			}
+			return c.Members[i].Councilmember.FullName < c.Members[j].Councilmember.FullName
+		})
+		sort.SliceStable(c.Referred, func(i, j int) bool { return c.Referred[i].IntroDate.After(c.Referred[j].IntroDate) })
+		sort.SliceStable(c.VotedOut, func(i, j int) bool { return c.VotedOut[i].Date.After(*c.VotedOut[j].Date) })
+		sort.SliceStable(c.Hearings, func(i, j int) bool { return c.Hearings[i].Date.After(c.Hearings[j].Date) })
+		sort.SliceStable(c.Upcoming, func(i, j int) bool { return c.Upcoming[i].Date.Before(c.Upcoming[j].Date) })
+		o = append(o, *c)
+	}
+	sort.Slice(o, func(i, j int) bool { return o[i].Name() < o[j].Name() })
+	return o, nil
+}
+
+// Committees lists the committees for a session at /committees
+func (a *App) Committees(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	t := newTemplate(a.templateFS, "committees.html")
+
+	type Page struct {
+		Page       string
+		Title      string
+		LastSync   LastSync
+		Session    Session
+		Sessions   []Session
+		Committees []Committee
+	}
+	body := Page{
+		Page:     "councilmembers",
+		Title:    "NYC Council Committees",
+		Session:  CurrentSession,
+		Sessions: Sessions,
+	}
+	for _, s := range Sessions {
+		if s.String() == r.Form.Get("session") {
+			body.Session = s
+		}
+	}
+	var err error
+	body.Committees, err = a.getCommittees(r.Context(), body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	cacheTTL := time.Minute * 30
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, "committees.html", body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// Committee shows the members, legislation, hearings and attendance for a committee
+// at /committees/$slug (or /committees/$slug.json)
+func (a *App) Committee(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	key := strings.TrimSuffix(r.PathValue("committee"), ".json")
+
+	type Page struct {
+		Page      string
+		Title     string
+		LastSync  LastSync
+		Session   Session
+		Sessions  []Session
+		Committee Committee
+	}
+	body := Page{
+		Page:     "councilmembers",
+		Session:  CurrentSession,
+		Sessions: Sessions,
+	}
+	for _, s := range Sessions {
+		if s.String() == r.Form.Get("session") {
+			body.Session = s
+		}
+	}
+	committees, err := a.getCommittees(r.Context(), body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	var found bool
+	for _, c := range committees {
+		if c.Slug == key {
+			body.Committee, found = c, true
+			break
+		}
+	}
+	if !found {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	body.Title = body.Committee.BodyName
+
+	cacheTTL := time.Minute * 15
+	if !body.Session.IsCurrent() {
+		cacheTTL = time.Hour * 24
+	}
+	a.addExpireHeaders(w, cacheTTL)
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body.Committee)
+		return
+	}
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	t := newTemplate(a.templateFS, "committee.html")
+	w.Header().Set("content-type", "text/html")
+	err = t.ExecuteTemplate(w, "committee.html", body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
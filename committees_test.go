@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommittee(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	start := CurrentSession.StartDate().Format(time.RFC3339)
+	future := now.AddDate(1, 0, 0).Format(time.RFC3339)
+	year := CurrentSession.StartYear
+	files := map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		"build/people_all.json": fmt.Sprintf(`[
+			{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","OfficeRecords":[
+				{"BodyName":"Committee on Transportation and Infrastructure","MemberType":"CHAIR","Title":"CHAIRPERSON","Start":%[1]q,"End":%[2]q},
+				{"BodyName":"Democratic Conference of the Council of the City of New York ","Start":%[1]q,"End":%[2]q}]},
+			{"ID":2,"Slug":"john-roe","FullName":"John Roe","OfficeRecords":[
+				{"BodyName":"Committee on Transportation and Infrastructure","Title":"Committee Member","Start":%[1]q,"End":%[2]q},
+				{"BodyName":"Committee on Parks and Recreation","Title":"Committee Member","Start":%[1]q,"End":%[2]q}]},
+			{"ID":3,"Slug":"sam-poe","FullName":"Sam Poe","OfficeRecords":[
+				{"BodyName":"Committee on Transportation and Infrastructure","Start":%[1]q,"End":%[1]q}]}]`, start, future),
+		fmt.Sprintf("build/%d.json", year): fmt.Sprintf(`[
+			{"File":"Int 0001-%[1]d","Name":"Bike Lanes","StatusName":"Committee","BodyName":"Committee on Transportation and Infrastructure","IntroDate":"%[1]d-01-08T15:00:00Z","Sponsors":[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe"}]},
+			{"File":"Int 0002-%[1]d","Name":"Buses","StatusName":"Enacted","BodyName":"City Council","IntroDate":"%[1]d-01-08T15:00:00Z",
+				"History":[{"Action":"Approved by Committee","BodyName":"Committee on Transportation and Infrastructure","Date":"%[1]d-01-20T15:00:00Z"}]},
+			{"File":"Int 0003-%[1]d","Name":"Parks","StatusName":"Committee","BodyName":"Committee on Parks and Recreation","IntroDate":"%[1]d-01-08T15:00:00Z"}]`, year),
+	}
+	// events_$year.json
+	events := make(map[int][]string)
+	for i, d := range []time.Time{now.AddDate(0, 0, -2), now.AddDate(0, 0, 2), now.AddDate(0, 0, -3)} {
+		status := "Final"
+		if i == 2 {
+			status = "Deferred"
+		}
+		events[d.Year()] = append(events[d.Year()], fmt.Sprintf(`{"ID":%d,"BodyName":"Committee on Transportation and Infrastructure","AgendaStatusName":%q,"Date":%q,
+			"Items":[{"MatterFile":"Int 0001-%d","MatterName":"Bike Lanes","MatterType":"Introduction"}]}`, 100+i, status, d.Format(time.RFC3339), year))
+	}
+	for y, e := range events {
+		files[fmt.Sprintf("build/events_%d.json", y)] = "[" + strings.Join(e, ",") + "]"
+	}
+	files[fmt.Sprintf("build/events_attendance_%d.json", now.AddDate(0, 0, -2).Year())] = `[
+		{"ID":100,"BodyName":"Committee on Transportation and Infrastructure","Items":[{"RollCall":[{"ID":1,"ValueID":13},{"ID":2,"ValueID":16}]},{"RollCall":[{"ID":1,"ValueID":13},{"ID":2,"ValueID":4}]}]}]`
+	app := newTestApp(t, files)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/committees/transportation-and-infrastructure.json", nil)
+	r.SetPathValue("committee", "transportation-and-infrastructure.json")
+	app.Committee(w, r)
+	if w.Code != 200 {
+		t.Fatalf("status %d %s", w.Code, w.Body)
+	}
+	var c Committee
+	if err := json.Unmarshal(w.Body.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Members) != 2 || !c.Members[0].Chair || c.Members[0].Party != "D" || c.Members[0].RollCallPercent != 100 ||
+		c.Members[1].ExpectedRollCall != 1 || c.Members[1].RollCall != 0 {
+		t.Errorf("unexpected members %#v", c.Members)
+	}
+	if len(c.Referred) != 1 || c.Referred[0].File != fmt.Sprintf("Int 0001-%d", year) {
+		t.Errorf("unexpected referred %#v", c.Referred)
+	}
+	if len(c.VotedOut) != 1 || c.VotedOut[0].File != fmt.Sprintf("Int 0002-%d", year) || c.VotedOut[0].Date == nil {
+		t.Errorf("unexpected voted out %#v", c.VotedOut)
+	}
+	if len(c.Hearings) != 1 || c.Hearings[0].ID != 100 || len(c.Hearings[0].Legislation) != 1 {
+		t.Errorf("unexpected hearings %#v", c.Hearings)
+	}
+	if len(c.Upcoming) != 1 || c.Upcoming[0].ID != 101 {
+		t.Errorf("unexpected upcoming %#v", c.Upcoming)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/committees/transportation-and-infrastructure", nil)
+	r.SetPathValue("committee", "transportation-and-infrastructure")
+	app.Committee(w, r)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Jane Doe") {
+		t.Errorf("status %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	app.Committees(w, httptest.NewRequest("GET", "/committees", nil))
+	if body := w.Body.String(); w.Code != 200 || !strings.Contains(body, `href="/committees/parks-and-recreation"`) {
+		t.Errorf("status %d %s", w.Code, body)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/committees/finance", nil)
+	r.SetPathValue("committee", "finance")
+	app.Committee(w, r)
+	if w.Code != 404 {
+		t.Errorf("expected 404 got %d", w.Code)
+	}
+}
@@ -1,17 +1,16 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"net/http"
-	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/jehiah/legislator/db"
 )
 
@@ -93,10 +92,29 @@ func (s Status) CSSClass() string {
 // Councilmember returns the list of councilmembers at /councilmembers/$name
 //
 // Redirects from /councilmembers/$district -> /councilmembers/$name
+//
+// type=resolution or type=all include resolutions
 func (a *App) Councilmember(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	introType, introTypes, err := introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
 
 	councilmember := r.PathValue("councilmember")
 	// log.Printf("Councilmember %q", councilmember)
+	switch {
+	case strings.HasSuffix(councilmember, ".atom"):
+		a.CouncilmemberFeed(w, r, strings.TrimSuffix(councilmember, ".atom"), introTypes)
+		return
+	case strings.HasSuffix(councilmember, ".ics"):
+		a.CouncilmemberCalendar(w, r, strings.TrimSuffix(councilmember, ".ics"))
+		return
+	case strings.HasSuffix(councilmember, ".csv"):
+		a.CouncilmemberCSV(w, r, strings.TrimSuffix(councilmember, ".csv"), introTypes)
+		return
+	}
 
 	if i, _ := strconv.Atoi(councilmember); i > 0 && i <= 51 {
 		var metadata []PersonMetadata
@@ -128,45 +146,19 @@ func (a *App) Councilmember(w http.ResponseWriter, r *http.Request) {
 
 	t := newTemplate(a.templateFS, "councilmember.html")
 
-	var people []db.Person
-	err := a.getJSONFile(r.Context(), "build/people_all.json", &people)
+	person, err := a.getCouncilmember(r.Context(), councilmember)
 	if err != nil {
-		if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
-			a.addExpireHeaders(w, time.Minute*5)
-			http.Error(w, "Not Found", 404)
-			return
-		}
 		log.Print(err)
 		http.Error(w, "Internal Server Error", 500)
 		return
 	}
-	var person Person
-	for _, p := range people {
-		if p.Slug == councilmember {
-			person = Person{Person: p}
-			break
-		}
-	}
-	if person.Person.Slug == "" {
+	if person == nil {
 		log.Printf("council member %q not found", councilmember)
 		a.addExpireHeaders(w, time.Minute*5)
 		http.Error(w, "Not Found", 404)
 		return
 	}
 
-	var metadata []PersonMetadata
-	err = a.getJSONFile(r.Context(), "build/people_metadata.json", &metadata)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-	for _, m := range metadata {
-		if m.ID == person.Person.ID {
-			person.PersonMetadata = m
-		}
-	}
-
 	cacheTTL := time.Minute * 15
 	if person.End.Before(time.Now()) {
 		cacheTTL = time.Hour
@@ -180,27 +172,34 @@ func (a *App) Councilmember(w http.ResponseWriter, r *http.Request) {
 		PrimarySponsor   LegislationList
 		SecondarySponsor LegislationList
 		CurrentSession   Session
+		IntroType        string
+		History          []SessionSponsorship // former members
 	}
 	body := Page{
 		Page:           "councilmembers",
-		Person:         person,
+		Person:         *person,
 		CurrentSession: CurrentSession,
+		IntroType:      introType,
 	}
 
 	if person.IsActive {
 
 		// TODO: some files may be cached from previous sessions
-		err = a.getJSONFile(r.Context(), fmt.Sprintf("build/legislation_%s.json", person.Person.Slug), &body.Legislation)
+		body.Legislation, err = a.getSponsorLegislation(r.Context(), person.Person.Slug, introTypes)
 		if err != nil {
-			// not found is ok; it means they are likely not active in current session (yet?)
-			if err != storage.ErrObjectNotExist {
-				log.Print(err)
-				http.Error(w, "Internal Server Error", 500)
-				return
-			}
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
 		}
 		body.PrimarySponsor = body.Legislation.FilterPrimarySponsor(person.ID())
 		body.SecondarySponsor = body.Legislation.FilterSecondarySponsor(person.ID())
+	} else {
+		body.History, err = a.getSponsorshipHistory(r.Context(), *person, introTypes)
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
 	}
 
 	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
@@ -219,3 +218,88 @@ func (a *App) Councilmember(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// SessionSponsorship is the legislation a council member sponsored in a session
+type SessionSponsorship struct {
+	Session          Session
+	PrimarySponsor   LegislationList
+	SecondarySponsor LegislationList
+}
+
+// getSponsorshipHistory returns the legislation sponsored by a council member in each session
+// they served (newest first) loading only the years in their City Council office records
+func (a *App) getSponsorshipHistory(ctx context.Context, person Person, introTypes []string) ([]SessionSponsorship, error) {
+	var o []SessionSponsorship
+	for _, year := range person.CouncilYears() {
+		s := FindSession(year)
+		if s == (Session{}) {
+			continue
+		}
+		var sponsored LegislationList
+		for _, introType := range introTypes {
+			var l LegislationList
+			err := a.getJSONFile(ctx, legislationFile(introType, year, ""), &l)
+			if err != nil {
+				if isNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			for _, ll := range l {
+				if ll.SponsoredBy(person.ID()) {
+					sponsored = append(sponsored, ll)
+				}
+			}
+		}
+		if len(sponsored) == 0 {
+			continue
+		}
+		if len(o) == 0 || o[len(o)-1].Session != s {
+			o = append(o, SessionSponsorship{Session: s})
+		}
+		h := &o[len(o)-1]
+		h.PrimarySponsor = append(h.PrimarySponsor, sponsored.FilterPrimarySponsor(person.ID())...)
+		h.SecondarySponsor = append(h.SecondarySponsor, sponsored.FilterSecondarySponsor(person.ID())...)
+	}
+	slices.Reverse(o)
+	return o, nil
+}
+
+var councilmemberSlugRegex = regexp.MustCompile("^[a-z0-9-]+$")
+
+// getCouncilmember returns the Person (with metadata) for a slug or nil if not found
+func (a *App) getCouncilmember(ctx context.Context, councilmember string) (*Person, error) {
+	if !councilmemberSlugRegex.MatchString(councilmember) {
+		return nil, nil
+	}
+	var people []db.Person
+	err := a.getJSONFile(ctx, "build/people_all.json", &people)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var person *Person
+	for _, p := range people {
+		if p.Slug == councilmember {
+			person = &Person{Person: p}
+			break
+		}
+	}
+	if person == nil {
+		return nil, nil
+	}
+
+	var metadata []PersonMetadata
+	err = a.getJSONFile(ctx, "build/people_metadata.json", &metadata)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metadata {
+		if m.ID == person.Person.ID {
+			person.PersonMetadata = m
+		}
+	}
+	return person, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gosimple/slug"
+)
+
+// maxFeedEntries limits the number of entries in a feed
+const maxFeedEntries = 100
+
+// CouncilmemberFeed is an Atom feed of recent actions on legislation sponsored by a council member
+// URL: /councilmembers/$name.atom
+func (a *App) CouncilmemberFeed(w http.ResponseWriter, r *http.Request, councilmember string, introTypes []string) {
+	person, err := a.getCouncilmember(r.Context(), councilmember)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if person == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	var legislation LegislationList
+	if person.IsActive {
+		legislation, err = a.getSponsorLegislation(r.Context(), person.Person.Slug, introTypes)
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+	}
+
+	link := "https://intro.nyc/councilmembers/" + person.Person.Slug
+	feed := AtomFeed{
+		ID:       link,
+		Title:    fmt.Sprintf("%s Legislation", person.FullName),
+		Subtitle: fmt.Sprintf("Recent actions on legislation sponsored by Council Member %s", person.FullName),
+		Links: []AtomLink{
+			{Href: link + ".atom", Rel: "self", Type: "application/atom+xml"},
+			{Href: link, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	type action struct {
+		l Legislation
+		h History
+	}
+	var actions []action
+	for _, l := range legislation {
+		for _, h := range l.History {
+			actions = append(actions, action{l, History{h}})
+		}
+	}
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].h.Date.After(actions[j].h.Date) })
+	for _, aa := range actions[:min(len(actions), maxFeedEntries)] {
+		feed.Entries = append(feed.Entries, newHistoryEntry(aa.l, aa.h))
+	}
+
+	cacheTTL := time.Minute * 15
+	if person.End.Before(time.Now()) {
+		cacheTTL = time.Hour
+	}
+	a.addExpireHeaders(w, cacheTTL)
+	a.writeAtom(w, feed)
+}
+
+// CouncilmemberCalendar is an iCalendar feed of upcoming events for the committees a council member serves on
+// URL: /councilmembers/$name.ics
+func (a *App) CouncilmemberCalendar(w http.ResponseWriter, r *http.Request, councilmember string) {
+	person, err := a.getCouncilmember(r.Context(), councilmember)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if person == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	committees := make(map[string]bool)
+	for _, or := range person.ActiveOfficeRecords() {
+		committees[TrimCommittee(or.BodyName)] = true
+	}
+
+	body := EventPage{
+		Session:      CurrentSession,
+		CalendarName: fmt.Sprintf("%s Committee Calendar", person.FullName),
+		CalendarFeed: "https://intro.nyc/councilmembers/" + person.Person.Slug + ".ics",
+	}
+
+	events, err := a.getEvents(r.Context(), body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	// show till start of previous month
+	now := a.Now().In(americaNewYork).Truncate(time.Hour * 24)
+	_, _, d := now.Date()
+	now = now.AddDate(0, -1, -1*d)
+	for _, e := range events {
+		if !committees[TrimCommittee(e.BodyName)] || e.Date.Before(now) {
+			continue
+		}
+		body.Events = append(body.Events, e)
+	}
+
+	a.addExpireHeaders(w, time.Minute*15)
+	a.CalendarFile(w, body)
+}
+
+// CouncilmemberCSV lists the legislation a council member introduced or sponsored
+// URL: /councilmembers/$name.csv
+//
+// Optional parameters session=2022-2023 (defaults to the current session, or every session for former members),
+// committee=$slug and sponsor=$slug (legislation also sponsored by another council member)
+func (a *App) CouncilmemberCSV(w http.ResponseWriter, r *http.Request, councilmember string, introTypes []string) {
+	person, err := a.getCouncilmember(r.Context(), councilmember)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if person == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	var selectedSession Session
+	for _, s := range Sessions {
+		if s.String() == r.Form.Get("session") {
+			selectedSession = s
+		}
+	}
+	selectedCommittee := r.Form.Get("committee")
+	selectedSponsor := r.Form.Get("sponsor")
+
+	var sessions []SessionSponsorship
+	if person.IsActive && (selectedSession == Session{} || selectedSession == CurrentSession) {
+		legislation, err := a.getSponsorLegislation(r.Context(), person.Person.Slug, introTypes)
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+		sessions = append(sessions, SessionSponsorship{
+			Session:          CurrentSession,
+			PrimarySponsor:   legislation.FilterPrimarySponsor(person.ID()),
+			SecondarySponsor: legislation.FilterSecondarySponsor(person.ID()),
+		})
+	} else {
+		history, err := a.getSponsorshipHistory(r.Context(), *person, introTypes)
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+		for _, h := range history {
+			if selectedSession == (Session{}) || h.Session == selectedSession {
+				sessions = append(sessions, h)
+			}
+		}
+	}
+
+	records := [][]string{{"Session", "Role", "File", "Name", "Status", "Committee", "Intro Date", "Primary Sponsor", "Sponsors", "URL"}}
+	for _, h := range sessions {
+		for i, list := range []LegislationList{h.PrimarySponsor, h.SecondarySponsor} {
+			role := []string{"Primary Sponsor", "Sponsor"}[i]
+			for _, l := range list {
+				if selectedCommittee != "" && slug.Make(TrimCommittee(l.BodyName)) != selectedCommittee {
+					continue
+				}
+				if selectedSponsor != "" && !l.SponsoredBySlug(selectedSponsor) {
+					continue
+				}
+				records = append(records, []string{h.Session.String(), role, l.File, l.Name, l.StatusName, l.BodyName, csvDate(l.IntroDate),
+					l.PrimarySponsor().FullName, csvInt(len(l.Sponsors)), "https://intro.nyc" + string(l.IntroLink())})
+			}
+		}
+	}
+
+	filename := fmt.Sprintf("%s.csv", person.Person.Slug)
+	if len(sessions) == 1 {
+		filename = fmt.Sprintf("%s_%s.csv", person.Person.Slug, sessions[0].Session)
+	}
+	cacheTTL := time.Minute * 15
+	if person.End.Before(time.Now()) {
+		cacheTTL = time.Hour
+	}
+	a.writeCSV(w, cacheTTL, filename, records)
+}
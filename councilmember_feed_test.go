@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCouncilmemberFeeds(t *testing.T) {
+	now := time.Date(CurrentSession.StartYear, time.June, 15, 12, 0, 0, 0, time.UTC)
+	future := "2099-12-31T00:00:00Z"
+	tomorrow := now.Add(time.Hour * 24)
+	app := newTestApp(t, map[string]string{
+		"build/people_all.json": fmt.Sprintf(`[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","IsActive":true,"End":%q,
+			"OfficeRecords":[{"BodyName":"Committee on Transportation and Infrastructure","End":%q},{"BodyName":"City Council","End":%q}]}]`, future, future, future),
+		"build/people_metadata.json": `[]`,
+		"build/legislation_jane-doe.json": `[{"File":"Int 0001-2024","Name":"Bike lanes","History":[
+			{"ID":10,"Date":"2024-01-10T10:00:00Z","Action":"Introduced by Council"},
+			{"ID":11,"Date":"2024-03-10T10:00:00Z","Action":"Hearing Held by Committee"}]}]`,
+		fmt.Sprintf("build/events_%d.json", tomorrow.Year()): fmt.Sprintf(`[
+			{"ID":100,"BodyName":"Committee on Transportation and Infrastructure","Date":%q},
+			{"ID":101,"BodyName":"Committee on Housing and Buildings","Date":%q}]`, tomorrow.Format(time.RFC3339), tomorrow.Format(time.RFC3339)),
+	})
+	app.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/councilmembers/jane-doe.atom", nil)
+	r.SetPathValue("councilmember", "jane-doe.atom")
+	app.Councilmember(w, r)
+	if w.Code != 200 {
+		t.Fatalf("atom status %d %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "<title>Int 0001-2024 Hearing Held by Committee</title>") || strings.Index(body, "history/11") > strings.Index(body, "history/10") {
+		t.Errorf("unexpected atom feed %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/councilmembers/jane-doe.ics", nil)
+	r.SetPathValue("councilmember", "jane-doe.ics")
+	app.Councilmember(w, r)
+	if w.Code != 200 {
+		t.Fatalf("ics status %d %s", w.Code, w.Body)
+	}
+	body = w.Body.String()
+	if !strings.Contains(body, "UID:100@intro.nyc") || strings.Contains(body, "UID:101@intro.nyc") {
+		t.Errorf("unexpected calendar %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/councilmembers/john-doe.ics", nil)
+	r.SetPathValue("councilmember", "john-doe.ics")
+	app.Councilmember(w, r)
+	if w.Code != 404 {
+		t.Errorf("expected 404 got %d", w.Code)
+	}
+}
+
+func TestCouncilmemberCSV(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"build/people_all.json": `[{"ID":2,"Slug":"john-smith-2","FullName":"John Smith","IsActive":false,"End":"2023-12-31T00:00:00Z",
+			"OfficeRecords":[{"BodyName":"City Council","Start":"2021-01-01T00:00:00Z","End":"2023-12-31T00:00:00Z"}]}]`,
+		"build/people_metadata.json": `[]`,
+		"build/2021.json":            `[{"File":"Int 0001-2021","Name":"Parks","BodyName":"Committee on Parks and Recreation","Sponsors":[{"ID":2,"Slug":"john-smith-2"}]}]`,
+		"build/2022.json": `[
+			{"File":"Int 0002-2022","Name":"Bike lanes","BodyName":"Committee on Transportation and Infrastructure","Sponsors":[{"ID":2,"Slug":"john-smith-2"},{"ID":3,"Slug":"jane-doe"}]},
+			{"File":"Int 0003-2022","Name":"Buses","BodyName":"Committee on Transportation and Infrastructure","Sponsors":[{"ID":3,"Slug":"jane-doe"},{"ID":2,"Slug":"john-smith-2"}]},
+			{"File":"Int 0004-2022","Name":"Housing","BodyName":"Committee on Housing and Buildings","Sponsors":[{"ID":2,"Slug":"john-smith-2"}]}]`,
+	})
+
+	get := func(qs string) []string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/councilmembers/john-smith-2.csv?"+qs, nil)
+		r.SetPathValue("councilmember", "john-smith-2.csv")
+		app.Councilmember(w, r)
+		if w.Code != 200 {
+			t.Fatalf("%s status %d %s", qs, w.Code, w.Body)
+		}
+		var files []string
+		for _, line := range strings.Split(strings.TrimSpace(w.Body.String()), "\n")[1:] {
+			f := strings.Split(line, ",")
+			files = append(files, f[0]+" "+f[1]+" "+f[2])
+		}
+		return files
+	}
+
+	for qs, expected := range map[string]string{
+		"":                  "2022-2023 Primary Sponsor Int 0002-2022|2022-2023 Primary Sponsor Int 0004-2022|2022-2023 Sponsor Int 0003-2022|2018-2021 Primary Sponsor Int 0001-2021",
+		"session=2018-2021": "2018-2021 Primary Sponsor Int 0001-2021",
+		"committee=transportation-and-infrastructure": "2022-2023 Primary Sponsor Int 0002-2022|2022-2023 Sponsor Int 0003-2022",
+		"session=2022-2023&sponsor=jane-doe":          "2022-2023 Primary Sponsor Int 0002-2022|2022-2023 Sponsor Int 0003-2022",
+		"session=2014-2017":                           "",
+	} {
+		if got := strings.Join(get(qs), "|"); got != expected {
+			t.Errorf("%q got %q expected %q", qs, got, expected)
+		}
+	}
+}
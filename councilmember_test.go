@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFormerCouncilmember(t *testing.T) {
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		"build/people_all.json": `[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","IsActive":false,"Start":"2014-01-01T00:00:00Z","End":"2019-12-31T00:00:00Z",
+			"OfficeRecords":[{"BodyName":"City Council","Start":"2014-01-01T00:00:00Z","End":"2019-12-31T00:00:00Z"}]}]`,
+		"build/people_metadata.json": `[]`,
+		"build/2017.json": `[
+			{"File":"Int 0001-2017","Name":"Bike Lanes","StatusName":"Enacted","Sponsors":[{"ID":1},{"ID":2}]},
+			{"File":"Int 0002-2017","Name":"Parks","StatusName":"Filed","Sponsors":[{"ID":1}]},
+			{"File":"Int 0003-2017","Name":"Housing","StatusName":"Enacted","Sponsors":[{"ID":2},{"ID":1}]},
+			{"File":"Int 0004-2017","Name":"Other","StatusName":"Enacted","Sponsors":[{"ID":2}]}]`,
+		"build/2018.json": `[{"File":"Int 0001-2018","Name":"Libraries","StatusName":"Filed","Sponsors":[{"ID":1}]}]`,
+		"build/2022.json": `[{"File":"Int 0001-2022","Name":"After Term","Sponsors":[{"ID":1}]}]`,
+	})
+
+	person, err := app.getCouncilmember(context.Background(), "jane-doe")
+	if err != nil || person == nil {
+		t.Fatalf("getCouncilmember %v %v", person, err)
+	}
+	history, err := app.getSponsorshipHistory(context.Background(), *person, []string{"introduction"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Session.String() != "2018-2021" || history[1].Session.String() != "2014-2017" {
+		t.Fatalf("unexpected history %#v", history)
+	}
+	h := history[1]
+	if h.PrimarySponsor.Number() != 2 || h.PrimarySponsor.Enacted().Number() != 1 || h.PrimarySponsor.EnactedPercent() != 50 || h.SecondarySponsor.Number() != 1 {
+		t.Errorf("unexpected 2014-2017 sponsorship %#v", h)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/councilmembers/jane-doe", nil)
+	r.SetPathValue("councilmember", "jane-doe")
+	app.Councilmember(w, r)
+	if w.Code != 200 {
+		t.Fatalf("status %d %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "2014-2017 Session") || !strings.Contains(body, "Libraries") || strings.Contains(body, "After Term") {
+		t.Errorf("unexpected page %s", body)
+	}
+}
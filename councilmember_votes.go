@@ -0,0 +1,289 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jehiah/legislator/db"
+)
+
+// MemberVote is a council member's vote on a roll call
+type MemberVote struct {
+	ID            int // History ID; see /votes/$file/$id
+	File          string
+	Name          string
+	Date          time.Time
+	Action        string
+	BodyName      string
+	Vote          string // "Affirmative", "Negative", "Abstain" or "" when absent or excused
+	PartyMajority string // how most of their party voted; "" when split
+	WithParty     bool
+}
+
+func (v MemberVote) IntroLink() string {
+	id, _ := ParseFile(v.File)
+	return "/" + string(id)
+}
+
+type MemberVotes []MemberVote
+
+// Count returns the number of votes that were vote ("Affirmative", "Negative", "Abstain")
+func (m MemberVotes) Count(vote string) int {
+	var n int
+	for _, v := range m {
+		if v.Vote == vote {
+			n++
+		}
+	}
+	return n
+}
+
+// PartyVotes returns the number of votes where their party had a majority position
+func (m MemberVotes) PartyVotes() int {
+	var n int
+	for _, v := range m {
+		if v.Vote != "" && v.PartyMajority != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// WithParty returns the number of votes in agreement with their party majority
+func (m MemberVotes) WithParty() int {
+	var n int
+	for _, v := range m {
+		if v.WithParty {
+			n++
+		}
+	}
+	return n
+}
+
+func (m MemberVotes) WithPartyPercent() float64 {
+	if m.PartyVotes() == 0 {
+		return 0
+	}
+	return (float64(m.WithParty()) / float64(m.PartyVotes())) * 100
+}
+
+// Filter returns the votes that are one of votes; all votes when empty
+func (m MemberVotes) Filter(votes []string) MemberVotes {
+	if len(votes) == 0 {
+		return m
+	}
+	o := MemberVotes{}
+	for _, v := range m {
+		for _, vote := range votes {
+			if v.Vote == vote {
+				o = append(o, v)
+			}
+		}
+	}
+	return o
+}
+
+// CouncilmemberVotesResponse is the JSON response for /councilmembers/$name/votes.json
+type CouncilmemberVotesResponse struct {
+	Councilmember db.PersonReference
+	Party         string // "D", "R"
+	Session       string // i.e. "2024-2025"
+	LastSync      time.Time
+	Filters       map[string]string `json:",omitempty"` // i.e. vote
+	Votes         MemberVotes
+}
+
+// voteFilterParam parses vote=negative,abstain
+func voteFilterParam(r *http.Request) ([]string, bool) {
+	var o []string
+	for _, v := range strings.Split(r.Form.Get("vote"), ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "all":
+		case "negative":
+			o = append(o, "Negative")
+		case "abstain":
+			o = append(o, "Abstain")
+		default:
+			return nil, false
+		}
+	}
+	return o, true
+}
+
+// getMemberVotes returns every vote by person in a session (newest first) from build/$year_votes.json
+// with how the majority of their party voted on the same roll call
+func (a *App) getMemberVotes(ctx context.Context, person Person, s Session) (MemberVotes, error) {
+	var people []db.Person
+	err := a.getJSONFile(ctx, "build/people_all.json", &people)
+	if err != nil {
+		return nil, err
+	}
+	party := make(map[int]string, len(people))
+	for _, p := range people {
+		party[p.ID] = Person{Person: p}.PartyShort()
+	}
+	memberParty := person.PartyShort()
+
+	l, err := a.getSessionLegislation(ctx, s, []string{"introduction", "resolution"}, "_votes")
+	if err != nil {
+		return nil, err
+	}
+	o := MemberVotes{}
+	for _, ll := range l {
+		for _, h := range ll.History {
+			var vote *db.Vote
+			positions := make(map[string]int)
+			for i, v := range h.Votes {
+				if v.ID == person.ID() {
+					vote = &h.Votes[i]
+				}
+				if memberParty != "" && party[v.ID] == memberParty {
+					if result := voteResult(v); result != "" {
+						positions[result]++
+					}
+				}
+			}
+			if vote == nil {
+				continue
+			}
+			mv := MemberVote{
+				ID:       h.ID,
+				File:     ll.File,
+				Name:     ll.Name,
+				Date:     h.Date,
+				Action:   h.Action,
+				BodyName: h.BodyName,
+				Vote:     voteResult(*vote),
+			}
+			var most int
+			for result, n := range positions {
+				switch {
+				case n > most:
+					most = n
+					mv.PartyMajority = result
+				case n == most:
+					mv.PartyMajority = ""
+				}
+			}
+			mv.WithParty = mv.Vote != "" && mv.Vote == mv.PartyMajority
+			o = append(o, mv)
+		}
+	}
+	sort.SliceStable(o, func(i, j int) bool {
+		if o[i].Date.Equal(o[j].Date) {
+			return o[i].File < o[j].File
+		}
+		return o[i].Date.After(o[j].Date)
+	})
+	return o, nil
+}
+
+// CouncilmemberVotes lists how a council member voted on each roll call in a session at /councilmembers/$name/votes
+//
+// vote=negative, vote=abstain or vote=negative,abstain limit the votes listed
+func (a *App) CouncilmemberVotes(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	voteFilter, ok := voteFilterParam(r)
+	if !ok {
+		http.Error(w, "unknown vote", 400)
+		return
+	}
+	ctx := r.Context()
+
+	person, err := a.getCouncilmember(ctx, r.PathValue("councilmember"))
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if person == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	type Page struct {
+		Page     string
+		Person   Person
+		LastSync LastSync
+		Session  Session
+		Sessions []Session
+		Vote     string
+		AllVotes MemberVotes
+		Votes    MemberVotes
+	}
+	body := Page{
+		Page:    "councilmembers",
+		Person:  *person,
+		Session: CurrentSession,
+		Vote:    r.Form.Get("vote"),
+	}
+	for _, year := range person.CouncilYears() {
+		s := FindSession(year)
+		if s != (Session{}) && (len(body.Sessions) == 0 || body.Sessions[0] != s) {
+			body.Sessions = append([]Session{s}, body.Sessions...)
+		}
+	}
+	switch {
+	case len(body.Sessions) == 0:
+		body.Sessions = []Session{CurrentSession}
+	case !person.IsActive:
+		body.Session = body.Sessions[0]
+	}
+	for _, s := range Sessions {
+		if s.String() == r.Form.Get("session") {
+			body.Session = s
+		}
+	}
+
+	body.AllVotes, err = a.getMemberVotes(ctx, *person, body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	body.Votes = body.AllVotes.Filter(voteFilter)
+
+	err = a.getJSONFile(ctx, "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	cacheTTL := time.Minute * 15
+	if !body.Session.IsCurrent() {
+		cacheTTL = time.Hour * 24
+	}
+	a.addExpireHeaders(w, cacheTTL)
+
+	if wantsJSON(r) {
+		resp := CouncilmemberVotesResponse{
+			Councilmember: db.PersonReference{ID: person.ID(), Slug: person.Person.Slug, FullName: person.FullName},
+			Party:         person.PartyShort(),
+			Session:       body.Session.String(),
+			LastSync:      body.LastSync.LastRun,
+			Votes:         body.Votes,
+		}
+		if body.Vote != "" {
+			resp.Filters = map[string]string{"vote": body.Vote}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	t := newTemplate(a.templateFS, "councilmember_votes.html")
+	w.Header().Set("content-type", "text/html")
+	err = t.ExecuteTemplate(w, "councilmember_votes.html", body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
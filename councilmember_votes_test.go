@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCouncilmemberVotes(t *testing.T) {
+	year := CurrentSession.StartYear
+	democrat := `"OfficeRecords":[{"BodyName":"City Council","Start":"2022-01-01T00:00:00Z","End":"2029-12-31T00:00:00Z"},{"BodyName":"Democratic Conference of the Council of the City of New York "}]`
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		"build/people_all.json": fmt.Sprintf(`[
+			{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","IsActive":true,%s},
+			{"ID":2,"Slug":"john-roe","FullName":"John Roe","IsActive":true,%s},
+			{"ID":3,"Slug":"sam-poe","FullName":"Sam Poe","IsActive":true,%s}]`, democrat, democrat, democrat),
+		"build/people_metadata.json": `[]`,
+		fmt.Sprintf("build/%d_votes.json", year): fmt.Sprintf(`[
+			{"File":"Int 0001-%[1]d","Name":"Bike Lanes","History":[
+				{"ID":10,"Action":"Approved by Committee","Date":"%[1]d-03-01T00:00:00Z","Votes":[{"ID":1,"VoteID":15},{"ID":2,"VoteID":15},{"ID":3,"VoteID":15}]},
+				{"ID":11,"Action":"Approved by Council","Date":"%[1]d-04-01T00:00:00Z","Votes":[{"ID":1,"VoteID":12},{"ID":2,"VoteID":15},{"ID":3,"VoteID":15}]}]},
+			{"File":"Int 0002-%[1]d","Name":"Parks","History":[
+				{"ID":12,"Action":"Approved by Council","Date":"%[1]d-05-01T00:00:00Z","Votes":[{"ID":1,"VoteID":11},{"ID":2,"VoteID":15}]},
+				{"ID":13,"Action":"Approved by Council","Date":"%[1]d-06-01T00:00:00Z","Votes":[{"ID":2,"VoteID":15}]}]}]`, year),
+	})
+
+	get := func(url string) CouncilmemberVotesResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", url, nil)
+		r.SetPathValue("councilmember", "jane-doe")
+		app.CouncilmemberVotes(w, r)
+		if w.Code != 200 {
+			t.Fatalf("GET %s status %d %s", url, w.Code, w.Body)
+		}
+		var resp CouncilmemberVotesResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := get("/councilmembers/jane-doe/votes.json")
+	if resp.Party != "D" || len(resp.Votes) != 3 {
+		t.Fatalf("unexpected response %#v", resp)
+	}
+	v := resp.Votes
+	if v[0].ID != 12 || v[0].Vote != "Abstain" || v[0].PartyMajority != "" || v[0].WithParty {
+		t.Errorf("unexpected vote %#v", v[0])
+	}
+	if v[1].ID != 11 || v[1].Vote != "Negative" || v[1].PartyMajority != "Affirmative" || v[1].WithParty {
+		t.Errorf("unexpected vote %#v", v[1])
+	}
+	if v[2].ID != 10 || v[2].Vote != "Affirmative" || !v[2].WithParty || v[2].Name != "Bike Lanes" {
+		t.Errorf("unexpected vote %#v", v[2])
+	}
+	if v.PartyVotes() != 2 || v.WithParty() != 1 {
+		t.Errorf("PartyVotes %d WithParty %d", v.PartyVotes(), v.WithParty())
+	}
+
+	resp = get("/councilmembers/jane-doe/votes.json?vote=negative,abstain")
+	if len(resp.Votes) != 2 || resp.Filters["vote"] != "negative,abstain" {
+		t.Errorf("unexpected filtered response %#v", resp)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/councilmembers/jane-doe/votes", nil)
+	r.SetPathValue("councilmember", "jane-doe")
+	app.CouncilmemberVotes(w, r)
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "1 of 2 votes") {
+		t.Errorf("status %d %s", w.Code, w.Body)
+	}
+}
@@ -128,6 +128,25 @@ func (p Person) CouncilTitle() string {
 	return ""
 }
 
+// CouncilYears returns the years (oldest first) covered by City Council office records
+func (p Person) CouncilYears() []int {
+	years := make(map[int]bool)
+	for _, oo := range p.OfficeRecords {
+		if oo.BodyName != "City Council" {
+			continue
+		}
+		for y := oo.Start.Year(); y <= oo.End.Year() && y <= time.Now().Year(); y++ {
+			years[y] = true
+		}
+	}
+	var o []int
+	for y := range years {
+		o = append(o, y)
+	}
+	sort.Ints(o)
+	return o
+}
+
 func (p Person) ActiveOfficeRecords() []db.OfficeRecord {
 	var final []db.OfficeRecord
 	now := time.Now()
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DiffChunk is a run of text that is unchanged, inserted or deleted
+type DiffChunk struct {
+	Op   string // equal, insert, delete
+	Text string
+}
+
+func (d DiffChunk) IsInsert() bool { return d.Op == "insert" }
+func (d DiffChunk) IsDelete() bool { return d.Op == "delete" }
+
+var diffTokenRegex = regexp.MustCompile(`\s+|[^\s]+`)
+
+// maxDiffEdits bounds the time used to compute a diff; texts with more edits
+// than this are shown as a full replacement. Memory is linear in the length
+// of the texts.
+const maxDiffEdits = 4000
+
+// DiffWords returns a word level diff between a and b
+func DiffWords(a, b string) []DiffChunk {
+	return mergeChunks(diffTokens(diffTokenRegex.FindAllString(a, -1), diffTokenRegex.FindAllString(b, -1)))
+}
+
+// diffTokens implements the linear space variant of the Myers O(ND) diff algorithm
+func diffTokens(a, b []string) []DiffChunk {
+	var o []DiffChunk
+	if diffRecursive(a, b, &o) {
+		return o
+	}
+	o = nil
+	for _, t := range a {
+		o = append(o, DiffChunk{"delete", t})
+	}
+	for _, t := range b {
+		o = append(o, DiffChunk{"insert", t})
+	}
+	return o
+}
+
+// diffRecursive appends the edits from a to b to o by splitting at the middle snake;
+// false if more than maxDiffEdits edits are needed
+func diffRecursive(a, b []string, o *[]DiffChunk) bool {
+	// trim common prefix and suffix
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		*o = append(*o, DiffChunk{"equal", a[0]})
+		a, b = a[1:], b[1:]
+	}
+	var suffix []string
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		suffix = append(suffix, a[len(a)-1])
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	switch {
+	case len(a) == 0:
+		for _, t := range b {
+			*o = append(*o, DiffChunk{"insert", t})
+		}
+	case len(b) == 0:
+		for _, t := range a {
+			*o = append(*o, DiffChunk{"delete", t})
+		}
+	default:
+		x, y, u, v, ok := middleSnake(a, b)
+		if !ok {
+			return false
+		}
+		if !diffRecursive(a[:x], b[:y], o) {
+			return false
+		}
+		for _, t := range a[x:u] {
+			*o = append(*o, DiffChunk{"equal", t})
+		}
+		if !diffRecursive(a[u:], b[v:], o) {
+			return false
+		}
+	}
+	for i := len(suffix) - 1; i >= 0; i-- {
+		*o = append(*o, DiffChunk{"equal", suffix[i]})
+	}
+	return true
+}
+
+// middleSnake finds the snake (x,y)-(u,v) in the middle of a shortest edit
+// script by searching forward from the start and backward from the end at
+// the same time; false if more than maxDiffEdits edits are needed
+func middleSnake(a, b []string) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := min((n+m+1)/2, (maxDiffEdits+1)/2)
+	offset := maxD + 1
+	// furthest reaching x on each diagonal k, forward (vf) and backward (vb) where the
+	// backward search runs on the reversed texts (diagonal delta-k)
+	vf := make([]int, 2*offset+1)
+	vb := make([]int, 2*offset+1)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1] // down; insert from b
+			} else {
+				x = vf[offset+k-1] + 1 // right; delete from a
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			if kr := delta - k; odd && kr >= -(d-1) && kr <= d-1 && x+vb[offset+kr] >= n {
+				return x0, y0, x, y, true
+			}
+		}
+		for kr := -d; kr <= d; kr += 2 {
+			var x int
+			if kr == -d || (kr != d && vb[offset+kr-1] < vb[offset+kr+1]) {
+				x = vb[offset+kr+1]
+			} else {
+				x = vb[offset+kr-1] + 1
+			}
+			y := x - kr
+			x0, y0 := x, y
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			vb[offset+kr] = x
+			if k := delta - kr; !odd && k >= -d && k <= d && x+vf[offset+k] >= n {
+				return n - x, m - y, n - x0, m - y0, true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// mergeChunks joins adjacent chunks with the same Op
+func mergeChunks(c []DiffChunk) []DiffChunk {
+	var o []DiffChunk
+	for _, cc := range c {
+		if len(o) > 0 && o[len(o)-1].Op == cc.Op {
+			o[len(o)-1].Text += cc.Text
+			continue
+		}
+		o = append(o, cc)
+	}
+	return o
+}
+
+// diffStats returns the number of words inserted and deleted
+func diffStats(c []DiffChunk) (inserted, deleted int) {
+	for _, cc := range c {
+		switch cc.Op {
+		case "insert":
+			inserted += len(strings.Fields(cc.Text))
+		case "delete":
+			deleted += len(strings.Fields(cc.Text))
+		}
+	}
+	return
+}
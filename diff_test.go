@@ -0,0 +1,129 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffWords(t *testing.T) {
+	type testCase struct {
+		a, b   string
+		expect []DiffChunk
+	}
+	tests := []testCase{
+		{
+			a:      "the quick brown fox",
+			b:      "the quick brown fox",
+			expect: []DiffChunk{{"equal", "the quick brown fox"}},
+		},
+		{
+			a: "the quick brown fox",
+			b: "the slow brown fox jumps",
+			expect: []DiffChunk{
+				{"equal", "the "},
+				{"delete", "quick"},
+				{"insert", "slow"},
+				{"equal", " brown fox"},
+				{"insert", " jumps"},
+			},
+		},
+		{
+			a: "a b c d",
+			b: "a d",
+			expect: []DiffChunk{
+				{"equal", "a "},
+				{"delete", "b c "},
+				{"equal", "d"},
+			},
+		},
+		{
+			a:      "",
+			b:      "new text",
+			expect: []DiffChunk{{"insert", "new text"}},
+		},
+	}
+	for _, tc := range tests {
+		got := DiffWords(tc.a, tc.b)
+		if !reflect.DeepEqual(got, tc.expect) {
+			t.Errorf("DiffWords(%q, %q) = %#v want %#v", tc.a, tc.b, got, tc.expect)
+		}
+		// reconstruct both sides
+		var a, b string
+		for _, c := range got {
+			if c.Op != "insert" {
+				a += c.Text
+			}
+			if c.Op != "delete" {
+				b += c.Text
+			}
+		}
+		if a != tc.a || b != tc.b {
+			t.Errorf("DiffWords(%q, %q) reconstructs %q, %q", tc.a, tc.b, a, b)
+		}
+	}
+}
+
+func TestDiffTokensShortest(t *testing.T) {
+	// lcs returns the length of the longest common subsequence of a and b
+	lcs := func(a, b []string) int {
+		dp := make([][]int, len(a)+1)
+		for i := range dp {
+			dp[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					dp[i][j] = dp[i+1][j+1] + 1
+				} else {
+					dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+				}
+			}
+		}
+		return dp[0][0]
+	}
+	r := rand.New(rand.NewSource(1))
+	words := func() []string {
+		o := make([]string, r.Intn(12))
+		for i := range o {
+			o[i] = string(rune('a' + r.Intn(3)))
+		}
+		return o
+	}
+	for i := 0; i < 1000; i++ {
+		a, b := words(), words()
+		var gotA, gotB []string
+		var equal int
+		for _, c := range diffTokens(a, b) {
+			if c.Op != "insert" {
+				gotA = append(gotA, c.Text)
+			}
+			if c.Op != "delete" {
+				gotB = append(gotB, c.Text)
+			}
+			if c.Op == "equal" {
+				equal++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("diffTokens(%v, %v) reconstructs %v, %v", a, b, gotA, gotB)
+		}
+		if expect := lcs(a, b); equal != expect {
+			t.Fatalf("diffTokens(%v, %v) kept %d tokens, expected %d", a, b, equal, expect)
+		}
+	}
+}
+
+func TestDiffTokensLimit(t *testing.T) {
+	var a, b []string
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, "a"+strconv.Itoa(i))
+		b = append(b, "b"+strconv.Itoa(i))
+	}
+	got := diffTokens(a, b)
+	if len(got) != 2*maxDiffEdits || got[0].Op != "delete" || got[len(got)-1].Op != "insert" {
+		t.Errorf("expected a full replacement; got %d chunks", len(got))
+	}
+}
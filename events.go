@@ -2,17 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
 	ics "github.com/arran4/golang-ical"
 	"github.com/gosimple/slug"
 	"github.com/jehiah/legislator/db"
@@ -20,12 +19,163 @@ import (
 
 type Event struct {
 	db.Event
-	Items []EventItem `json:",omitempty"`
+	Items    []EventItem `json:",omitempty"`
+	Sequence int         `json:",omitempty"` // incremented each time the agenda, status or date changes (see Indexer)
+
+	// set by linkEvents
+	End             time.Time       `json:"-"`
+	RescheduledFrom *EventReference `json:",omitempty"` // the deferred event this replaces
+	RescheduledTo   *EventReference `json:",omitempty"` // the event replacing a deferred event
+}
+
+type EventReference struct {
+	ID        int
+	Date      time.Time
+	InSiteURL string
+}
+
+// eventDurations are the expected length of a meeting by BodyName; Legistar only publishes a start time
+var eventDurations = map[string]time.Duration{
+	"City Council": time.Hour * 3, // Stated Meeting
 }
+
+const (
+	committeeEventDuration    = time.Hour * 2
+	subcommitteeEventDuration = time.Hour
+)
+
 type EventItem struct {
 	db.EventItem
 }
 
+func (e Event) Reference() *EventReference {
+	return &EventReference{ID: e.ID, Date: e.Date, InSiteURL: e.InSiteURL}
+}
+
+// Link returns the path of the event page i.e. /events/2024/1234
+func (e Event) Link() string {
+	return eventLink(e.Date, e.ID)
+}
+
+// Link returns the path of the event page i.e. /events/2024/1234
+func (e EventReference) Link() string {
+	return eventLink(e.Date, e.ID)
+}
+
+func eventLink(date time.Time, id int) string {
+	return fmt.Sprintf("/events/%d/%d", date.In(americaNewYork).Year(), id)
+}
+
+func (e Event) IsDeferred() bool {
+	return e.AgendaStatusName == "Deferred"
+}
+
+// defaultDuration returns the expected length of a meeting of e.BodyName
+func (e Event) defaultDuration() time.Duration {
+	if d, ok := eventDurations[e.BodyName]; ok {
+		return d
+	}
+	if strings.HasPrefix(e.BodyName, "Subcommittee") {
+		return subcommitteeEventDuration
+	}
+	return committeeEventDuration
+}
+
+// EndAt returns the expected end of the event
+func (e Event) EndAt() time.Time {
+	if !e.End.IsZero() {
+		return e.End
+	}
+	return e.Date.Add(e.defaultDuration())
+}
+
+// sharesAgenda returns true when a and b have any legislation (or oversight topic) in common
+func sharesAgenda(a, b Event) bool {
+	seen := make(map[string]bool)
+	for _, i := range a.Items {
+		if i.MatterFile != "" {
+			seen[i.MatterFile] = true
+		}
+	}
+	for _, i := range b.Items {
+		if i.MatterFile != "" && seen[i.MatterFile] {
+			return true
+		}
+	}
+	return false
+}
+
+// linkEvents sets the expected End of each event and links deferred events to the event that replaces them
+//
+// An event ends at the default duration for the body or when the next meeting of the same body that day starts.
+// A deferred event is rescheduled to the nearest (by date) event of the same body created after it (higher ID)
+// within 60 days that shares an agenda item, or any such event when the deferred event has no agenda.
+func linkEvents(events []Event) {
+	byBody := make(map[string][]int)
+	for i, e := range events {
+		byBody[e.BodyName] = append(byBody[e.BodyName], i)
+	}
+	for _, idx := range byBody {
+		sort.SliceStable(idx, func(a, b int) bool { return events[idx[a]].Date.Before(events[idx[b]].Date) })
+		for n, i := range idx {
+			e := &events[i]
+			e.End = e.Date.Add(e.defaultDuration())
+			for _, j := range idx[n+1:] {
+				next := events[j]
+				if next.IsDeferred() || !next.Date.After(e.Date) {
+					continue
+				}
+				if next.Date.Before(e.End) {
+					e.End = next.Date
+				}
+				break
+			}
+		}
+
+		for _, i := range idx {
+			d := &events[i]
+			if !d.IsDeferred() {
+				continue
+			}
+			best := -1
+			var bestDiff time.Duration
+			for _, j := range idx {
+				c := events[j]
+				if c.IsDeferred() || c.ID < d.ID || c.RescheduledFrom != nil || c.Date.Equal(d.Date) {
+					continue
+				}
+				diff := c.Date.Sub(d.Date)
+				if diff < 0 {
+					diff = -diff
+				}
+				if diff > time.Hour*24*60 {
+					continue
+				}
+				if len(d.Items) > 0 && !sharesAgenda(*d, c) {
+					continue
+				}
+				if best == -1 || diff < bestDiff {
+					best, bestDiff = j, diff
+				}
+			}
+			if best != -1 {
+				d.RescheduledTo = events[best].Reference()
+				events[best].RescheduledFrom = d.Reference()
+			}
+		}
+	}
+}
+
+// HasLegislation returns true when any of files (i.e. "Int 0001-2024") are on the agenda
+func (e Event) HasLegislation(files map[string]bool) bool {
+	for _, i := range e.Items {
+		if files[i.MatterFile] {
+			return true
+		}
+	}
+	return false
+}
+
 func (e EventItem) IsDraft() bool {
 	if e.MatterFile == "" {
 		return false
@@ -49,13 +199,20 @@ type EventPage struct {
 
 	Session           Session
 	IsCurrentSession  bool
+	Year              int   // set for /events/$year
+	Years             []int // newest first
 	Committees        []string
 	SelectedCommittee string
+	CalendarName      string
 	CalendarFeed      string
 
 	Events []Event
 }
 
+// Events lists upcoming events at /events (or /events.ics)
+//
+// Filter with committee=$committee_slug (repeated or comma separated), sponsor=$councilmember_slug
+// for events with legislation sponsored by a council member, and file=1234-2024 for events with a bill on the agenda
 func (a *App) Events(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	templateName := "events.html"
@@ -69,6 +226,7 @@ func (a *App) Events(w http.ResponseWriter, r *http.Request) {
 		Title:            "NYC Council Events",
 		Session:          CurrentSession,
 		IsCurrentSession: true,
+		Years:            eventYears(),
 	}
 
 	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
@@ -78,7 +236,42 @@ func (a *App) Events(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	selectedCommittee := r.Form.Get("committee")
+	selectedCommittees := committeeParam(r)
+	var filters []string // describes the filters for CalendarName
+	var sponsor, file string
+	var sponsorFiles map[string]bool
+	if sponsor = r.Form.Get("sponsor"); sponsor != "" {
+		person, err := a.getCouncilmember(r.Context(), sponsor)
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+		if person == nil {
+			http.Error(w, "unknown sponsor", 400)
+			return
+		}
+		legislation, err := a.getSponsorLegislation(r.Context(), person.Person.Slug, []string{"introduction", "resolution"})
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+		sponsorFiles = make(map[string]bool, len(legislation))
+		for _, l := range legislation {
+			sponsorFiles[l.File] = true
+		}
+		filters = append(filters, fmt.Sprintf("legislation sponsored by %s", person.FullName))
+	}
+	if file = r.Form.Get("file"); file != "" {
+		id, err := ParseIntroID(file)
+		if err != nil {
+			http.Error(w, "invalid file", 400)
+			return
+		}
+		file = id.File()
+		filters = append(filters, file)
+	}
 
 	committees := make(map[string]bool)
 	eventCount := make(map[string]int)
@@ -104,32 +297,49 @@ func (a *App) Events(w http.ResponseWriter, r *http.Request) {
 		_, _, d := now.Date()
 		now = now.AddDate(0, -1, -1*d)
 	}
-	for year := body.Session.StartYear; year <= body.Session.EndYear && year <= time.Now().Year(); year++ {
-
-		var events []Event
-		err = a.getJSONFile(r.Context(), fmt.Sprintf("build/events_%d.json", year), &events)
-		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
-				continue
-			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+	events, err := a.getEvents(r.Context(), body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	committeeNames := make(map[string]string)
+	for _, e := range events {
+		eventCount[TrimCommittee(e.BodyName)]++
+		eventCommittee := slug.Make(TrimCommittee(e.BodyName))
+		if len(selectedCommittees) > 0 && !selectedCommittees[eventCommittee] {
+			continue
 		}
-		for _, e := range events {
-			eventCount[TrimCommittee(e.BodyName)]++
-			eventCommittee := slug.Make(TrimCommittee(e.BodyName))
-			if eventCommittee != selectedCommittee && selectedCommittee != "" {
-				continue
-			}
-			if selectedCommittee != "" && eventCommittee == selectedCommittee {
-				body.SelectedCommittee = e.BodyName
-			}
-			if e.Date.Before(now) {
-				continue
-			}
-			body.Events = append(body.Events, e)
+		if len(selectedCommittees) > 0 {
+			committeeNames[eventCommittee] = e.BodyName
+		}
+		if e.Date.Before(now) {
+			continue
+		}
+		if sponsorFiles != nil && !e.HasLegislation(sponsorFiles) {
+			continue
+		}
+		if file != "" && !e.HasLegislation(map[string]bool{file: true}) {
+			continue
 		}
+		body.Events = append(body.Events, e)
+	}
+	var committeeSlugs, names []string
+	for c, name := range committeeNames {
+		committeeSlugs = append(committeeSlugs, c)
+		names = append(names, TrimCommittee(name))
+		body.SelectedCommittee = name
+	}
+	sort.Strings(committeeSlugs)
+	sort.Strings(names)
+	if len(committeeNames) > 1 || (len(committeeNames) == 1 && len(filters) > 0) {
+		filters = append([]string{strings.Join(names, ", ")}, filters...)
+	}
+	if len(committeeNames) > 1 {
+		body.SelectedCommittee = ""
+	}
+	if len(filters) > 0 {
+		body.CalendarName = fmt.Sprintf("NYC Council Calendar for %s", strings.Join(filters, "; "))
 	}
 
 	for b, _ := range committees {
@@ -141,8 +351,14 @@ func (a *App) Events(w http.ResponseWriter, r *http.Request) {
 	sort.Strings(body.Committees)
 
 	v := &url.Values{}
-	if body.SelectedCommittee != "" {
-		v.Set("committee", slug.Make(TrimCommittee(body.SelectedCommittee)))
+	if len(committeeSlugs) > 0 {
+		v.Set("committee", strings.Join(committeeSlugs, ","))
+	}
+	if sponsor != "" {
+		v.Set("sponsor", sponsor)
+	}
+	if file != "" {
+		v.Set("file", r.Form.Get("file"))
 	}
 	body.CalendarFeed = (&url.URL{
 		Scheme:   "https",
@@ -167,39 +383,77 @@ func (a *App) Events(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getEvents returns all events for a session (see linkEvents)
+func (a *App) getEvents(ctx context.Context, s Session) ([]Event, error) {
+	var o []Event
+	for year := s.StartYear; year <= s.EndYear && year <= a.Now().Year(); year++ {
+		var events []Event
+		err := a.getJSONFile(ctx, fmt.Sprintf("build/events_%d.json", year), &events)
+		if err != nil {
+			if isNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		o = append(o, events...)
+	}
+	linkEvents(o)
+	return o, nil
+}
+
+// CalendarFile writes body.Events as an iCalendar file
+//
+// The calendar name defaults to the SelectedCommittee (or CalendarName when set)
+// and the URL to body.CalendarFeed
 func (a *App) CalendarFile(w http.ResponseWriter, body EventPage) {
 	cal := ics.NewCalendar()
 	cal.SetMethod(ics.MethodPublish)
 
-	if body.SelectedCommittee != "" {
+	switch {
+	case body.CalendarName != "":
+		cal.SetName(body.CalendarName)
+		cal.SetDescription(body.CalendarName)
+	case body.SelectedCommittee != "":
 		cal.SetName(body.SelectedCommittee)
 		cal.SetDescription(fmt.Sprintf("NYC Council Calendar for %s", TrimCommittee(body.SelectedCommittee)))
-	} else {
+	default:
 		cal.SetName("New York City Council Calendar")
 	}
 	cal.SetRefreshInterval("P1H") // 1 hour?
-	v := &url.Values{}
-	if body.SelectedCommittee != "" {
-		v.Set("committee", slug.Make(TrimCommittee(body.SelectedCommittee)))
-	}
-	u := url.URL{
-		Scheme:   "https",
-		Host:     "intro.nyc",
-		Path:     "/events.ics",
-		RawQuery: v.Encode(),
+	if body.CalendarFeed != "" {
+		cal.SetUrl(body.CalendarFeed)
+	} else {
+		v := &url.Values{}
+		if body.SelectedCommittee != "" {
+			v.Set("committee", slug.Make(TrimCommittee(body.SelectedCommittee)))
+		}
+		u := url.URL{
+			Scheme:   "https",
+			Host:     "intro.nyc",
+			Path:     "/events.ics",
+			RawQuery: v.Encode(),
+		}
+		cal.SetUrl(u.String())
 	}
-	cal.SetUrl(u.String())
 
 	for _, e := range body.Events {
-		if e.AgendaStatusName == "Deferred" {
-			continue
-		}
 		event := cal.AddEvent(fmt.Sprintf("%d@intro.nyc", e.ID))
 		event.SetCreatedTime(e.AgendaLastPublished)
 		event.SetDtStampTime(e.AgendaLastPublished)
 		event.SetModifiedAt(e.LastModified.Add(time.Second))
+		event.SetSequence(e.Sequence)
 		event.SetStartAt(e.Date)
-		event.SetEndAt(e.Date.Add(time.Hour))
+		event.SetEndAt(e.EndAt())
+		if e.IsDeferred() {
+			// let calendars that already have the event know it won't happen
+			event.SetStatus(ics.ObjectStatusCancelled)
+		}
+		if e.RescheduledTo != nil {
+			event.AddProperty(ics.ComponentProperty(ics.PropertyRelatedTo), fmt.Sprintf("%d@intro.nyc", e.RescheduledTo.ID))
+		}
+		if e.RescheduledFrom != nil {
+			event.AddProperty(ics.ComponentProperty(ics.PropertyRelatedTo), fmt.Sprintf("%d@intro.nyc", e.RescheduledFrom.ID))
+		}
 		event.SetSummary(TrimCommittee(e.BodyName))
 		if e.Location != "" {
 			event.SetLocation(e.Location)
@@ -209,6 +463,15 @@ func (a *App) CalendarFile(w http.ResponseWriter, body EventPage) {
 		if e.AgendaStatusName != "Final" {
 			fmt.Fprintf(desc, "Status: %s\n", e.AgendaStatusName)
 		}
+		if e.RescheduledTo != nil {
+			fmt.Fprintf(desc, "Rescheduled to %s\n", e.RescheduledTo.Date.In(americaNewYork).Format("Monday, January 2 3:04pm"))
+		}
+		if e.RescheduledFrom != nil {
+			fmt.Fprintf(desc, "Rescheduled from %s\n", e.RescheduledFrom.Date.In(americaNewYork).Format("Monday, January 2 3:04pm"))
+		}
+		if e.Sequence > 0 {
+			fmt.Fprintf(desc, "Agenda changed %s\n", e.AgendaLastPublished.In(americaNewYork).Format("Jan 2, 2006 3:04pm"))
+		}
 		for _, i := range e.Items {
 			switch i.MatterType {
 			case "Oversight":
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosimple/slug"
+)
+
+// EventsYear lists every event in a year at /events/$year
+//
+// Other numbers are event IDs; /events/$id (or /events/$id.json) redirects to /events/$year/$id
+func (a *App) EventsYear(w http.ResponseWriter, r *http.Request) {
+	v := r.PathValue("year")
+	if year, err := strconv.Atoi(v); err == nil && len(v) == 4 && sessionForYear(year) != nil {
+		a.eventsYear(w, r, year)
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimSuffix(v, ".json"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	for _, year := range eventYears() {
+		event, err := a.findEvent(r.Context(), year, id)
+		if err != nil {
+			log.Print(err)
+			http.Error(w, "Internal Server Error", 500)
+			return
+		}
+		if event == nil {
+			continue
+		}
+		target := fmt.Sprintf("/events/%d/%d", year, id)
+		if strings.HasSuffix(v, ".json") {
+			target += ".json"
+		}
+		a.addExpireHeaders(w, time.Hour*24)
+		http.Redirect(w, r, target, 301)
+		return
+	}
+	a.addExpireHeaders(w, time.Minute*5)
+	http.Error(w, "Not Found", 404)
+}
+
+// sessionForYear returns the Session including year (up to the current year)
+func sessionForYear(year int) *Session {
+	if year > time.Now().Year() {
+		return nil
+	}
+	for _, s := range Sessions {
+		if s.StartYear <= year && year <= s.EndYear {
+			return &s
+		}
+	}
+	return nil
+}
+
+// eventYears returns the years covered by Sessions up to the current year (newest first)
+func eventYears() []int {
+	var o []int
+	for y := time.Now().Year(); y >= Sessions[len(Sessions)-1].StartYear; y-- {
+		o = append(o, y)
+	}
+	return o
+}
+
+// committeeParam returns the slugs selected with committee= (repeated or comma separated)
+func committeeParam(r *http.Request) map[string]bool {
+	selected := make(map[string]bool)
+	for _, c := range r.Form["committee"] {
+		for _, c := range strings.Split(c, ",") {
+			if c != "" {
+				selected[c] = true
+			}
+		}
+	}
+	return selected
+}
+
+func (a *App) eventsYear(w http.ResponseWriter, r *http.Request, year int) {
+	r.ParseForm()
+	templateName := "events.html"
+	t := newTemplate(a.templateFS, templateName)
+
+	s := sessionForYear(year)
+	body := EventPage{
+		Page:             "events",
+		Title:            "NYC Council Events " + strconv.Itoa(year),
+		Session:          *s,
+		IsCurrentSession: s.IsCurrent(),
+		Year:             year,
+		Years:            eventYears(),
+	}
+
+	err := a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	events, err := a.getEvents(r.Context(), *s)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	selectedCommittees := committeeParam(r)
+	committees := make(map[string]bool)
+	for _, e := range events {
+		if e.Date.In(americaNewYork).Year() != year {
+			continue
+		}
+		committees[TrimCommittee(e.BodyName)] = true
+		eventCommittee := slug.Make(TrimCommittee(e.BodyName))
+		if len(selectedCommittees) > 0 && !selectedCommittees[eventCommittee] {
+			continue
+		}
+		if len(selectedCommittees) == 1 {
+			body.SelectedCommittee = e.BodyName
+		}
+		body.Events = append(body.Events, e)
+	}
+	sort.SliceStable(body.Events, func(i, j int) bool { return body.Events[i].Date.Before(body.Events[j].Date) })
+	for c := range committees {
+		body.Committees = append(body.Committees, c)
+	}
+	sort.Strings(body.Committees)
+
+	w.Header().Set("content-type", "text/html")
+	cacheTTL := time.Minute * 15
+	if year < time.Now().Year() {
+		cacheTTL = time.Hour * 24
+	}
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, templateName, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// findEvent returns event id from build/events_$year.json; nil if not found
+func (a *App) findEvent(ctx context.Context, year, id int) (*Event, error) {
+	var events []Event
+	err := a.getJSONFile(ctx, fmt.Sprintf("build/events_%d.json", year), &events)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	i := slices.IndexFunc(events, func(e Event) bool { return e.ID == id })
+	if i == -1 {
+		return nil, nil
+	}
+	linkEvents(events)
+	return &events[i], nil
+}
+
+// Event shows the agenda of an event with the outcome of each item and links to the agenda,
+// minutes and video at /events/$year/$id (or /events/$year/$id.json)
+func (a *App) Event(w http.ResponseWriter, r *http.Request) {
+	year, err := strconv.Atoi(r.PathValue("year"))
+	if err != nil || sessionForYear(year) == nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimSuffix(r.PathValue("id"), ".json"))
+	if err != nil || id <= 0 {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	event, err := a.findEvent(r.Context(), year, id)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if event == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	cacheTTL := time.Minute * 15
+	if event.Date.Before(time.Now().AddDate(0, -3, 0)) {
+		cacheTTL = time.Hour * 24
+	}
+	a.addExpireHeaders(w, cacheTTL)
+
+	if strings.HasSuffix(r.PathValue("id"), ".json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(event)
+		return
+	}
+
+	type Page struct {
+		Page     string
+		Title    string
+		LastSync LastSync
+		Event    Event
+	}
+	body := Page{
+		Page:  "events",
+		Title: TrimCommittee(event.BodyName) + " " + event.Date.In(americaNewYork).Format("January 2, 2006"),
+		Event: *event,
+	}
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	t := newTemplate(a.templateFS, "event.html")
+	w.Header().Set("content-type", "text/html")
+	err = t.ExecuteTemplate(w, "event.html", body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
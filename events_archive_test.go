@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventArchive(t *testing.T) {
+	year := CurrentSession.StartYear
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		fmt.Sprintf("build/events_%d.json", year): fmt.Sprintf(`[
+			{"ID":100,"BodyName":"Committee on Transportation","Date":"%[1]d-01-20T15:00:00Z","AgendaStatusName":"Final",
+				"AgendaFile":"https://example.com/agenda.pdf","MinutesFile":"https://example.com/minutes.pdf","VideoPath":"https://example.com/video",
+				"Items":[
+					{"Title":"Roll Call"},
+					{"MatterFile":"Int 0001-%[1]d","MatterName":"Bike Lanes","MatterType":"Introduction","ActionName":"Approved by Committee","PassedFlagName":"Pass","Tally":"5-0-0"}]},
+			{"ID":101,"BodyName":"Committee on Parks","Date":"%[1]d-01-21T15:00:00Z","AgendaStatusName":"Deferred"},
+			{"ID":%[1]d,"BodyName":"Committee on Health","Date":"%[1]d-02-21T15:00:00Z","AgendaStatusName":"Final"}]`, year),
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/events/"), "/")
+		r.SetPathValue("year", parts[0])
+		if len(parts) == 1 {
+			app.EventsYear(w, r)
+			return w
+		}
+		r.SetPathValue("id", parts[1])
+		app.Event(w, r)
+		return w
+	}
+	link := func(id int) string { return fmt.Sprintf(`href="/events/%d/%d"`, year, id) }
+
+	w := get(fmt.Sprintf("/events/%d", year))
+	if body := w.Body.String(); w.Code != 200 || !strings.Contains(body, link(100)) || !strings.Contains(body, link(101)) {
+		t.Errorf("status %d %s", w.Code, body)
+	}
+	w = get(fmt.Sprintf("/events/%d?committee=parks", year))
+	if body := w.Body.String(); w.Code != 200 || strings.Contains(body, link(100)) || !strings.Contains(body, link(101)) {
+		t.Errorf("status %d %s", w.Code, body)
+	}
+
+	w = get(fmt.Sprintf("/events/%d/100", year))
+	body := w.Body.String()
+	if w.Code != 200 {
+		t.Fatalf("status %d %s", w.Code, body)
+	}
+	for _, expected := range []string{"https://example.com/agenda.pdf", "https://example.com/minutes.pdf", "https://example.com/video",
+		fmt.Sprintf(`href="/0001-%d+"`, year), "Approved by Committee", "5-0-0", "Roll Call"} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("missing %q in %s", expected, body)
+		}
+	}
+
+	w = get(fmt.Sprintf("/events/%d/100.json", year))
+	var e Event
+	if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || e.ID != 100 || len(e.Items) != 2 || e.VideoPath == "" {
+		t.Errorf("unexpected event %#v %v", e, err)
+	}
+
+	// an event ID in the range of years is still an event
+	if w := get(fmt.Sprintf("/events/%d/%d", year, year)); w.Code != 200 || !strings.Contains(w.Body.String(), "Health") {
+		t.Errorf("event %d got status %d", year, w.Code)
+	}
+
+	// /events/$id redirects to the year of the event
+	for path, location := range map[string]string{
+		"/events/100":      fmt.Sprintf("/events/%d/100", year),
+		"/events/100.json": fmt.Sprintf("/events/%d/100.json", year),
+	} {
+		if w := get(path); w.Code != 301 || w.Header().Get("Location") != location {
+			t.Errorf("%s got status %d location %q", path, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	for _, path := range []string{"/events/102", "/events/0", fmt.Sprintf("/events/%d/102", year), fmt.Sprintf("/events/%d/100", year-1),
+		fmt.Sprintf("/events/%d/x", year), "/events/x", fmt.Sprintf("/events/%d", year+10), fmt.Sprintf("/events/%d/100", year+10)} {
+		if w := get(path); w.Code != 404 {
+			t.Errorf("%s expected 404 got %d", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jehiah/legislator/db"
+)
+
+func TestEventsCalendar(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	future := now.AddDate(1, 0, 0).Format(time.RFC3339)
+	tomorrow := now.Add(time.Hour * 24)
+	event := func(id int, body, file string, sequence int) string {
+		return fmt.Sprintf(`{"ID":%d,"BodyName":%q,"Date":%q,"AgendaStatusName":"Final","AgendaLastPublished":%q,"Sequence":%d,
+			"Items":[{"MatterFile":%q,"MatterName":"Name","MatterType":"Introduction"}]}`, id, body, tomorrow.Format(time.RFC3339), now.Format(time.RFC3339), sequence, file)
+	}
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		"build/people_all.json": fmt.Sprintf(`[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","IsActive":true,"End":%q,
+			"OfficeRecords":[{"BodyName":"Committee on Transportation and Infrastructure","End":%q}]}]`, future, future),
+		"build/people_metadata.json":      `[]`,
+		"build/legislation_jane-doe.json": `[{"File":"Int 0002-2024","Name":"Bike lanes"}]`,
+		fmt.Sprintf("build/events_%d.json", tomorrow.Year()): "[" + strings.Join([]string{
+			event(100, "Committee on Transportation and Infrastructure", "Int 0001-2024", 0),
+			event(101, "Committee on Housing and Buildings", "Int 0002-2024", 2),
+			event(102, "Committee on Parks and Recreation", "Int 0003-2024", 0),
+			strings.Replace(event(103, "Committee on Parks and Recreation", "Int 0004-2024", 0), "Final", "Deferred", 1),
+		}, ",") + "]",
+	})
+
+	get := func(path string) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		app.Events(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 {
+			t.Fatalf("GET %s status %d %s", path, w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+	for path, expected := range map[string][]int{
+		"/events.ics": {100, 101, 102, 103},
+		"/events.ics?committee=transportation-and-infrastructure,housing-and-buildings":          {100, 101},
+		"/events.ics?committee=transportation-and-infrastructure&committee=parks-and-recreation": {100, 102, 103},
+		"/events.ics?sponsor=jane-doe": {101},
+		"/events.ics?file=0003-2024":   {102},
+		"/events.ics?committee=transportation-and-infrastructure&committee=parks-and-recreation&file=0003-2024": {102},
+	} {
+		body := get(path)
+		for _, id := range []int{100, 101, 102, 103} {
+			if got, want := strings.Contains(body, fmt.Sprintf("UID:%d@intro.nyc", id)), slices.Contains(expected, id); got != want {
+				t.Errorf("%s event %d got %v expected %v", path, id, got, want)
+			}
+		}
+	}
+
+	body := get("/events.ics?sponsor=jane-doe")
+	if !strings.Contains(body, "SEQUENCE:2") || !strings.Contains(body, "Agenda changed") ||
+		!strings.Contains(body, "NYC Council Calendar for legislation sponsored by Jane Doe") || !strings.Contains(body, "sponsor=jane-doe") {
+		t.Errorf("unexpected calendar %s", body)
+	}
+
+	body = get("/events.ics?file=0004-2024")
+	if !strings.Contains(body, "STATUS:CANCELLED") || !strings.Contains(body, "DTEND") {
+		t.Errorf("expected deferred event to be cancelled %s", body)
+	}
+
+	for _, path := range []string{"/events.ics?sponsor=john-roe", "/events.ics?file=x"} {
+		w := httptest.NewRecorder()
+		app.Events(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 400 {
+			t.Errorf("GET %s expected 400 got %d", path, w.Code)
+		}
+	}
+}
+
+func TestLinkEvents(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.RFC3339, s)
+		return d
+	}
+	event := func(id int, body, d, status string, files ...string) Event {
+		e := Event{Event: db.Event{ID: id, BodyName: body, Date: date(d), AgendaStatusName: status}}
+		for _, f := range files {
+			e.Items = append(e.Items, EventItem{db.EventItem{MatterFile: f}})
+		}
+		return e
+	}
+	events := []Event{
+		event(1, "Committee on Transportation", "2024-03-01T14:00:00Z", "Deferred", "Int 0001-2024"),
+		event(2, "Committee on Transportation", "2024-03-01T15:00:00Z", "Final", "Int 0002-2024"),
+		event(3, "Committee on Transportation", "2024-03-12T14:00:00Z", "Final", "Int 0003-2024"),
+		event(4, "Committee on Transportation", "2024-03-20T14:00:00Z", "Final", "Int 0001-2024", "Int 0003-2024"),
+		event(5, "Committee on Parks", "2024-03-02T14:00:00Z", "Final", "Int 0001-2024"),
+		event(6, "City Council", "2024-03-02T14:00:00Z", "Final"),
+		event(7, "Subcommittee on Zoning", "2024-03-02T14:00:00Z", "Deferred"),
+		event(8, "Subcommittee on Zoning", "2024-03-09T14:00:00Z", "Final"),
+	}
+	linkEvents(events)
+
+	if events[0].RescheduledTo == nil || events[0].RescheduledTo.ID != 4 || events[3].RescheduledFrom == nil || events[3].RescheduledFrom.ID != 1 {
+		t.Errorf("expected 1 rescheduled to 4 got %#v %#v", events[0].RescheduledTo, events[3].RescheduledFrom)
+	}
+	if events[6].RescheduledTo == nil || events[6].RescheduledTo.ID != 8 {
+		t.Errorf("expected 7 rescheduled to 8 got %#v", events[6].RescheduledTo)
+	}
+	if events[2].RescheduledFrom != nil || events[4].RescheduledFrom != nil {
+		t.Errorf("unexpected reschedule %#v %#v", events[2], events[4])
+	}
+	for i, expected := range []string{"2024-03-01T15:00:00Z", "2024-03-01T17:00:00Z", "", "", "", "2024-03-02T17:00:00Z", "", "2024-03-09T15:00:00Z"} {
+		if expected != "" && !events[i].EndAt().Equal(date(expected)) {
+			t.Errorf("event %d ends %s expected %s", events[i].ID, events[i].EndAt(), expected)
+		}
+	}
+}
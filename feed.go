@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// AtomFeed is an RFC 4287 Atom feed
+type AtomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  time.Time   `xml:"updated"`
+	Links    []AtomLink  `xml:"link"`
+	Author   *AtomPerson `xml:"author,omitempty"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    time.Time      `xml:"updated"`
+	Published  *time.Time     `xml:"published,omitempty"`
+	Links      []AtomLink     `xml:"link"`
+	Author     *AtomPerson    `xml:"author,omitempty"`
+	Categories []AtomCategory `xml:"category,omitempty"`
+	Content    *AtomText      `xml:"content,omitempty"`
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name" json:"name"`
+	URI  string `xml:"uri,omitempty" json:"url,omitempty"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type AtomText struct {
+	Type string `xml:"type,attr,omitempty"` // text, html
+	Body string `xml:",chardata"`
+}
+
+var atomAuthor = &AtomPerson{Name: "intro.nyc", URI: "https://intro.nyc/"}
+
+// atomID returns a stable tag: URI (RFC 4151) for a feed or entry
+func atomID(date time.Time, specific string) string {
+	return fmt.Sprintf("tag:intro.nyc,%s:%s", date.In(americaNewYork).Format("2006-01-02"), specific)
+}
+
+// writeAtom renders an Atom feed; the feed Updated time defaults to the latest entry (or now)
+func (a *App) writeAtom(w http.ResponseWriter, feed AtomFeed) {
+	for _, e := range feed.Entries {
+		if e.Updated.After(feed.Updated) {
+			feed.Updated = e.Updated
+		}
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Now().UTC().Truncate(time.Second)
+	}
+	if feed.Author == nil {
+		feed.Author = atomAuthor
+	}
+	if a.devMode {
+		w.Header().Set("Content-type", "text/plain")
+	} else {
+		w.Header().Set("Content-type", "application/atom+xml")
+	}
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		log.Print(err)
+	}
+}
+
+// JSONFeed is a JSON Feed version 1.1 https://www.jsonfeed.org/version/1.1/
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Authors     []AtomPerson   `json:"authors,omitempty"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            string    `json:"id"`
+	URL           string    `json:"url,omitempty"`
+	Title         string    `json:"title,omitempty"`
+	ContentText   string    `json:"content_text"`
+	DatePublished time.Time `json:"date_published"`
+	Tags          []string  `json:"tags,omitempty"`
+
+	// IntroNYC is a feed extension with structured data about the item
+	IntroNYC any `json:"_intro_nyc,omitempty"`
+}
+
+// writeJSONFeed renders a JSON Feed
+func (a *App) writeJSONFeed(w http.ResponseWriter, feed JSONFeed) {
+	feed.Version = "https://jsonfeed.org/version/1.1"
+	if len(feed.Authors) == 0 {
+		feed.Authors = []AtomPerson{*atomAuthor}
+	}
+	if a.devMode {
+		w.Header().Set("Content-type", "text/plain")
+	} else {
+		w.Header().Set("Content-type", "application/feed+json")
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIntroFeed(t *testing.T) {
+	ctx := context.Background()
+	app := newTestApp(t, nil)
+	archive := NewMemoryStore()
+	archive.Put(ctx, "introduction/2024/0012.json", "", strings.NewReader(`{"ID":123,"File":"Int 0012-2024","Name":"Test","IntroDate":"2024-01-10T10:00:00Z",
+		"History":[{"ID":1,"Date":"2024-01-10T10:00:00Z","Action":"Introduced by Council"},{"ID":2,"Date":"2024-02-01T10:00:00Z","Action":"Approved by Council","Votes":[{"VoteID":15,"Vote":"Affirmative"}]}]}`))
+	app.archive = archive
+	app.offline = true
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/0012-2024.atom", nil)
+	r.SetPathValue("file", "0012-2024.atom")
+	app.FileRedirect(w, r)
+	if w.Code != 200 {
+		t.Fatalf("got status %d %s", w.Code, w.Body)
+	}
+	body := w.Body.String()
+	for _, s := range []string{
+		"<id>tag:intro.nyc,2024-01-10:0012-2024/history/1</id>",
+		"<title>Int 0012-2024 Approved by Council</title>",
+		"<updated>2024-02-01T10:00:00Z</updated>",
+		"Votes: 1 in favor",
+	} {
+		if !strings.Contains(body, s) {
+			t.Errorf("missing %q in %s", s, body)
+		}
+	}
+	if strings.Index(body, "history/2") > strings.Index(body, "history/1") {
+		t.Errorf("expected newest entry first")
+	}
+}
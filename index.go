@@ -0,0 +1,571 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"time"
+
+	"github.com/jehiah/legislator/db"
+)
+
+// Indexer builds the build/*.json files served by intro.nyc from a checkout
+// of https://github.com/jehiah/nyc_legislation
+//
+//	introduction/$year/$number.json  db.Legislation
+//	resolution/$year/$number.json    db.Legislation
+//	events/$year/*.json              db.Event
+//	people/*.json                    db.Person
+//	people/appendix/people_metadata.json
+//	resubmit/$year.json
+//	last_sync.json
+type Indexer struct {
+	Source   fs.FS
+	Store    Store
+	Previous Store // the last published build (i.e. gs://intronyc) state is carried forward from; defaults to Store
+	Now      time.Time
+}
+
+// fields removed from legislation in build/$year.json and build/resolution_$year.json
+var (
+	indexLegislationOmit = []string{"RTF", "GUID", "BodyID", "EnactmentDate", "PassedDate", "Version", "TextID", "StatusID", "TypeID", "TypeName", "AgendaDate", "Text", "Attachments"}
+	indexHistoryOmit     = []string{"ActionID", "AgendaSequence", "MinutesSequence", "AgendaNumber", "Version", "MatterStatusID", "EventID", "LastModified", "ID", "BodyID", "Votes"}
+
+	// build/legislation_$slug.json and build/resolution_$slug.json
+	sponsorLegislationOmit = []string{"RTF", "GUID", "TextID", "StatusID", "TypeID", "TypeName", "AgendaDate", "Attachments", "Text", "Version"}
+	sponsorHistoryOmit     = []string{"Votes"}
+
+	// build/events_$year.json
+	indexEventOmit     = []string{"GUID", "VideoStatus"}
+	indexEventItemOmit = []string{"ID", "GUID", "MatterID", "LastModified", "Version", "MinutesNote", "ActionText", "PassedFlag", "RollCall"}
+
+	// build/events_attendance_$year.json
+	attendanceEventItemOmit = []string{"ID", "GUID", "MatterID", "LastModified", "Version", "MinutesNote", "ActionText", "PassedFlag", "AgendaSequence", "MinutesSequence"}
+	attendanceRollCallOmit  = []string{"FullName", "Slug", "Value", "Sort"}
+
+	// build/people_*.json
+	indexPersonOmit       = []string{"FirstName", "LastName", "GUID"}
+	indexOfficeRecordOmit = []string{"GUID", "FullName", "PersonID", "LastModified"}
+)
+
+// searchIndexActions are the ActionIDs used for SearchEntry.LastModified
+var searchIndexActions = map[int]bool{
+	27: true, // Introduced by Council
+	32: true, // Approved by Committee
+	33: true, // Amended by Committee
+	58: true, // City Charter Rule Adopted
+	68: true, // Approved by Council
+}
+
+// Build writes every build/*.json file for sessions.
+//
+// Files for individual council members are only built when sessions includes the CurrentSession.
+func (x *Indexer) Build(ctx context.Context, sessions []Session) error {
+	if x.Now.IsZero() {
+		x.Now = time.Now()
+	}
+	for _, s := range sessions {
+		if err := x.buildSession(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	people, err := x.readPeople()
+	if err != nil {
+		return err
+	}
+	var active []db.Person
+	for _, p := range people {
+		if p.IsActive && x.isCurrent(p) {
+			active = append(active, p)
+		}
+	}
+	if err := x.putPeople(ctx, "build/people_active.json", active); err != nil {
+		return err
+	}
+	if err := x.putPeople(ctx, "build/people_all.json", people); err != nil {
+		return err
+	}
+	if err := x.copy(ctx, "people/appendix/people_metadata.json", "build/people_metadata.json"); err != nil {
+		return err
+	}
+
+	if err := x.buildLocalLaws(ctx); err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		if s != CurrentSession {
+			continue
+		}
+		for _, introType := range []string{"introduction", "resolution"} {
+			var legislation []db.Legislation
+			for _, year := range x.years(s) {
+				l, err := x.readLegislation(introType, year)
+				if err != nil {
+					return err
+				}
+				legislation = append(legislation, l...)
+			}
+			for _, p := range people {
+				if !x.isCurrent(p) {
+					continue
+				}
+				if err := x.buildSponsorLegislation(ctx, introType, p, legislation); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	resubmit, err := fs.Glob(x.Source, "resubmit/*.json")
+	if err != nil {
+		return err
+	}
+	for _, f := range resubmit {
+		if err := x.copy(ctx, f, "build/resubmit_"+path.Base(f)); err != nil {
+			return err
+		}
+	}
+	return x.copy(ctx, "last_sync.json", "build/last_sync.json")
+}
+
+// isCurrent returns true for people whose term includes Now
+func (x *Indexer) isCurrent(p db.Person) bool {
+	return p.End.After(x.Now) && p.Start.Before(x.Now)
+}
+
+// years returns the years in a session up to Now
+func (x *Indexer) years(s Session) []int {
+	var o []int
+	for year := s.StartYear; year <= s.EndYear && year <= x.Now.Year(); year++ {
+		o = append(o, year)
+	}
+	return o
+}
+
+func (x *Indexer) buildSession(ctx context.Context, s Session) error {
+	for _, introType := range []string{"introduction", "resolution"} {
+		var search []SearchIndexRow
+		for _, year := range x.years(s) {
+			legislation, err := x.readLegislation(introType, year)
+			if err != nil {
+				return err
+			}
+			if len(legislation) == 0 {
+				continue
+			}
+			prefix := fmt.Sprintf("build/%d", year)
+			if introType == "resolution" {
+				prefix = fmt.Sprintf("build/resolution_%d", year)
+			}
+
+			var o []map[string]json.RawMessage
+			var votes []VotesIndexRow
+			for _, l := range legislation {
+				m, err := omitLegislationFields(l, indexLegislationOmit, indexHistoryOmit)
+				if err != nil {
+					return err
+				}
+				o = append(o, m)
+				votes = append(votes, NewVotesIndexRow(l))
+				search = append(search, NewSearchIndexRow(l))
+			}
+			log.Printf("building %s.json", prefix)
+			if err := x.put(ctx, prefix+".json", o); err != nil {
+				return err
+			}
+			log.Printf("building %s_votes.json", prefix)
+			if err := x.put(ctx, prefix+"_votes.json", votes); err != nil {
+				return err
+			}
+		}
+		if len(search) == 0 {
+			continue
+		}
+		log.Printf("building %s", searchIndexFile(s, introType))
+		if err := x.put(ctx, searchIndexFile(s, introType), search); err != nil {
+			return err
+		}
+	}
+
+	for _, year := range x.years(s) {
+		if err := x.buildEvents(ctx, year); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *Indexer) buildEvents(ctx context.Context, year int) error {
+	files, err := fs.Glob(x.Source, fmt.Sprintf("events/%d/*.json", year))
+	if err != nil || len(files) == 0 {
+		return err
+	}
+	// Sequence is carried forward from the last build and incremented when the agenda is republished,
+	// the status changes (i.e. to "Deferred") or the event is rescheduled
+	var previous []Event
+	if err := x.getPrevious(ctx, fmt.Sprintf("build/events_%d.json", year), &previous); err != nil {
+		return err
+	}
+	sequence := make(map[int]Event, len(previous))
+	for _, e := range previous {
+		sequence[e.ID] = e
+	}
+
+	var events, attendance []map[string]json.RawMessage
+	for _, f := range files {
+		var e db.Event
+		if err := x.readJSON(f, &e); err != nil {
+			return err
+		}
+
+		m, err := omitFields(e, indexEventOmit...)
+		if err != nil {
+			return err
+		}
+		if p, ok := sequence[e.ID]; ok {
+			seq := p.Sequence
+			if !p.AgendaLastPublished.Equal(e.AgendaLastPublished) || p.AgendaStatusName != e.AgendaStatusName || !p.Date.Equal(e.Date) {
+				seq++
+			}
+			if seq > 0 {
+				if m["Sequence"], err = json.Marshal(seq); err != nil {
+					return err
+				}
+			}
+		}
+		var items []map[string]json.RawMessage
+		for _, i := range e.Items {
+			im, err := omitFields(i, indexEventItemOmit...)
+			if err != nil {
+				return err
+			}
+			items = append(items, im)
+		}
+		if m["Items"], err = marshalList(items); err != nil {
+			return err
+		}
+		events = append(events, m)
+
+		// attendance only needs the items with a roll call
+		var rollCallItems []map[string]json.RawMessage
+		for _, i := range e.Items {
+			if i.RollCallFlag != 1 {
+				continue
+			}
+			im, err := omitFields(i, attendanceEventItemOmit...)
+			if err != nil {
+				return err
+			}
+			var rollCall []map[string]json.RawMessage
+			for _, rc := range i.RollCall {
+				rcm, err := omitFields(rc, attendanceRollCallOmit...)
+				if err != nil {
+					return err
+				}
+				rollCall = append(rollCall, rcm)
+			}
+			if im["RollCall"], err = marshalList(rollCall); err != nil {
+				return err
+			}
+			rollCallItems = append(rollCallItems, im)
+		}
+		am := map[string]json.RawMessage{"ID": m["ID"], "BodyID": m["BodyID"], "BodyName": m["BodyName"]}
+		if am["Items"], err = marshalList(rollCallItems); err != nil {
+			return err
+		}
+		attendance = append(attendance, am)
+	}
+	log.Printf("building build/events_%d.json", year)
+	if err := x.put(ctx, fmt.Sprintf("build/events_%d.json", year), events); err != nil {
+		return err
+	}
+	log.Printf("building build/events_attendance_%d.json", year)
+	return x.put(ctx, fmt.Sprintf("build/events_attendance_%d.json", year), attendance)
+}
+
+// buildLocalLaws indexes the Local Law number of every enacted introduction
+func (x *Indexer) buildLocalLaws(ctx context.Context) error {
+	files, err := fs.Glob(x.Source, "introduction/????/????.json")
+	if err != nil {
+		return err
+	}
+	type LocalLaw struct {
+		File, LocalLaw, Title string
+	}
+	o := []LocalLaw{}
+	for _, f := range files {
+		var l db.Legislation
+		if err := x.readJSON(f, &l); err != nil {
+			return err
+		}
+		if l.LocalLaw == "" {
+			continue
+		}
+		o = append(o, LocalLaw{File: l.File, LocalLaw: l.LocalLaw, Title: l.Title})
+	}
+	log.Print("building build/local_laws.json")
+	return x.put(ctx, "build/local_laws.json", o)
+}
+
+// buildSponsorLegislation writes the legislation sponsored by p to
+// build/legislation_$slug.json (or build/resolution_$slug.json)
+func (x *Indexer) buildSponsorLegislation(ctx context.Context, introType string, p db.Person, legislation []db.Legislation) error {
+	o := []map[string]json.RawMessage{}
+	for _, l := range legislation {
+		sponsored := false
+		for _, sponsor := range l.Sponsors {
+			if sponsor.ID == p.ID {
+				sponsored = true
+				break
+			}
+		}
+		if !sponsored {
+			continue
+		}
+		m, err := omitLegislationFields(l, sponsorLegislationOmit, sponsorHistoryOmit)
+		if err != nil {
+			return err
+		}
+		o = append(o, m)
+	}
+	filename := fmt.Sprintf("build/legislation_%s.json", p.Slug)
+	if introType == "resolution" {
+		filename = fmt.Sprintf("build/resolution_%s.json", p.Slug)
+	}
+	log.Printf("building %s", filename)
+	return x.put(ctx, filename, o)
+}
+
+func (x *Indexer) putPeople(ctx context.Context, filename string, people []db.Person) error {
+	o := []map[string]json.RawMessage{}
+	for _, p := range people {
+		m, err := omitFields(p, indexPersonOmit...)
+		if err != nil {
+			return err
+		}
+		var records []map[string]json.RawMessage
+		for _, or := range p.OfficeRecords {
+			om, err := omitFields(or, indexOfficeRecordOmit...)
+			if err != nil {
+				return err
+			}
+			records = append(records, om)
+		}
+		if m["OfficeRecords"], err = marshalList(records); err != nil {
+			return err
+		}
+		o = append(o, m)
+	}
+	log.Printf("building %s", filename)
+	return x.put(ctx, filename, o)
+}
+
+// readLegislation returns legislation from introduction/$year or resolution/$year ordered by file
+func (x *Indexer) readLegislation(introType string, year int) ([]db.Legislation, error) {
+	files, err := fs.Glob(x.Source, fmt.Sprintf("%s/%d/????.json", introType, year))
+	if err != nil {
+		return nil, err
+	}
+	var o []db.Legislation
+	for _, f := range files {
+		var l db.Legislation
+		if err := x.readJSON(f, &l); err != nil {
+			return nil, err
+		}
+		o = append(o, l)
+	}
+	return o, nil
+}
+
+func (x *Indexer) readPeople() ([]db.Person, error) {
+	files, err := fs.Glob(x.Source, "people/*.json")
+	if err != nil {
+		return nil, err
+	}
+	var o []db.Person
+	for _, f := range files {
+		var p db.Person
+		if err := x.readJSON(f, &p); err != nil {
+			return nil, err
+		}
+		o = append(o, p)
+	}
+	return o, nil
+}
+
+func (x *Indexer) readJSON(filename string, v interface{}) error {
+	body, err := fs.ReadFile(x.Source, filename)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%s %w", filename, err)
+	}
+	return nil
+}
+
+// getPrevious reads a file from the previous build in x.Previous (or x.Store); a missing file is not an error
+func (x *Indexer) getPrevious(ctx context.Context, filename string, v interface{}) error {
+	previous := x.Previous
+	if previous == nil {
+		previous = x.Store
+	}
+	r, _, err := previous.Get(ctx, filename)
+	if err != nil {
+		if isNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer r.Close()
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return fmt.Errorf("%s %w", filename, err)
+	}
+	return nil
+}
+
+func (x *Indexer) put(ctx context.Context, filename string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return x.Store.Put(ctx, filename, "application/json", bytes.NewReader(body))
+}
+
+// copy stores a source file as-is
+func (x *Indexer) copy(ctx context.Context, src, dst string) error {
+	body, err := fs.ReadFile(x.Source, src)
+	if err != nil {
+		return err
+	}
+	log.Printf("copying %s", dst)
+	return x.Store.Put(ctx, dst, "application/json", bytes.NewReader(body))
+}
+
+// VotesIndexRow is a row in build/$year_votes.json
+type VotesIndexRow struct {
+	File       string
+	Name       string
+	StatusID   int
+	StatusName string
+	Sponsors   []VotesIndexPerson
+	History    []VotesIndexHistory
+}
+
+type VotesIndexPerson struct {
+	ID int
+}
+
+type VotesIndexHistory struct {
+	ID             int // the roll call; see /votes/$file/$id
+	ActionID       int
+	Action         string
+	Date           time.Time
+	BodyName       string
+	PassedFlagName string
+	Votes          []VotesIndexVote
+}
+
+type VotesIndexVote struct {
+	ID     int
+	VoteID int
+}
+
+// NewVotesIndexRow keeps the sponsors and the votes from actions that passed or failed
+func NewVotesIndexRow(l db.Legislation) VotesIndexRow {
+	r := VotesIndexRow{
+		File:       l.File,
+		Name:       l.Name,
+		StatusID:   l.StatusID,
+		StatusName: l.StatusName,
+		Sponsors:   []VotesIndexPerson{},
+		History:    []VotesIndexHistory{},
+	}
+	for _, s := range l.Sponsors {
+		r.Sponsors = append(r.Sponsors, VotesIndexPerson{ID: s.ID})
+	}
+	for _, h := range l.History {
+		if h.PassedFlagName == "" {
+			continue
+		}
+		vh := VotesIndexHistory{
+			ID:             h.ID,
+			ActionID:       h.ActionID,
+			Action:         h.Action,
+			Date:           h.Date,
+			BodyName:       h.BodyName,
+			PassedFlagName: h.PassedFlagName,
+			Votes:          []VotesIndexVote{},
+		}
+		for _, v := range h.Votes {
+			vh.Votes = append(vh.Votes, VotesIndexVote{ID: v.ID, VoteID: v.VoteID})
+		}
+		r.History = append(r.History, vh)
+	}
+	return r
+}
+
+// SearchIndexRow is a row in build/search_index_$session.json (See SearchEntry)
+type SearchIndexRow struct {
+	File, Name, Title, Summary, StatusName string
+	LastModified                           *time.Time
+}
+
+// NewSearchIndexRow uses the date of the last significant action as LastModified
+func NewSearchIndexRow(l db.Legislation) SearchIndexRow {
+	r := SearchIndexRow{File: l.File, Name: l.Name, Title: l.Title, Summary: l.Summary, StatusName: l.StatusName}
+	for _, h := range l.History {
+		if searchIndexActions[h.ActionID] {
+			d := h.Date
+			r.LastModified = &d
+		}
+	}
+	return r
+}
+
+// omitFields returns v as a JSON object without fields (like jq's del)
+func omitFields(v interface{}, fields ...string) (map[string]json.RawMessage, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		delete(m, f)
+	}
+	return m, nil
+}
+
+func omitLegislationFields(l db.Legislation, fields, historyFields []string) (map[string]json.RawMessage, error) {
+	m, err := omitFields(l, fields...)
+	if err != nil {
+		return nil, err
+	}
+	var history []map[string]json.RawMessage
+	for _, h := range l.History {
+		hm, err := omitFields(h, historyFields...)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, hm)
+	}
+	m["History"], err = marshalList(history)
+	return m, err
+}
+
+// marshalList encodes a nil list as [] instead of null
+func marshalList(v []map[string]json.RawMessage) (json.RawMessage, error) {
+	if v == nil {
+		v = []map[string]json.RawMessage{}
+	}
+	return json.Marshal(v)
+}
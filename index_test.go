@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestIndexerBuild(t *testing.T) {
+	year := CurrentSession.StartYear
+	now := time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC)
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	source := fstest.MapFS{
+		fmt.Sprintf("introduction/%d/0001.json", year): file(fmt.Sprintf(`{"File":"Int 0001-%[1]d","Name":"Bike Lanes","GUID":"x","RTF":"{rtf}","Text":"text","LocalLaw":"%[1]d/001",
+			"Sponsors":[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe"}],
+			"History":[
+				{"ID":10,"ActionID":27,"Action":"Introduced by Council","Date":"%[1]d-02-01T00:00:00Z"},
+				{"ID":11,"ActionID":68,"Action":"Approved by Council","Date":"%[1]d-03-01T00:00:00Z","PassedFlagName":"Pass","Votes":[{"ID":1,"VoteID":15,"Vote":"Affirmative"}]},
+				{"ID":12,"ActionID":5,"Action":"Something Else","Date":"%[1]d-04-01T00:00:00Z"}]}`, year)),
+		fmt.Sprintf("introduction/%d/0002.json", year): file(fmt.Sprintf(`{"File":"Int 0002-%d","Sponsors":[{"ID":2}]}`, year)),
+		fmt.Sprintf("resolution/%d/0001.json", year):   file(fmt.Sprintf(`{"File":"Res 0001-%d","Sponsors":[{"ID":1}]}`, year)),
+		fmt.Sprintf("events/%d/1.json", year): file(`{"ID":1,"GUID":"x","BodyID":1,"BodyName":"City Council","VideoPath":"v","Items":[
+			{"ID":5,"Title":"Roll Call","RollCallFlag":1,"RollCall":[{"ID":1,"FullName":"Jane Doe","ValueID":13,"Value":"Present"}]},
+			{"ID":6,"Title":"Other"}]}`),
+		"people/jane-doe.json": file(fmt.Sprintf(`{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","FirstName":"Jane","IsActive":true,"Start":"2022-01-01T00:00:00Z","End":"%d-12-31T00:00:00Z",
+			"OfficeRecords":[{"BodyName":"City Council","GUID":"x","PersonID":1}]}`, CurrentSession.EndYear)),
+		"people/john-roe.json":                 file(`{"ID":2,"Slug":"john-roe","FullName":"John Roe","IsActive":false,"Start":"2014-01-01T00:00:00Z","End":"2017-12-31T00:00:00Z"}`),
+		"people/appendix/people_metadata.json": file(`[{"ID":1,"District":1}]`),
+		fmt.Sprintf("resubmit/%d.json", year):  file(`{}`),
+		"last_sync.json":                       file(fmt.Sprintf(`{"LastRun":"%d-06-01T00:00:00Z"}`, year)),
+	}
+	store := NewMemoryStore()
+	x := &Indexer{Source: source, Store: store, Now: now}
+	if err := x.Build(context.Background(), []Session{CurrentSession}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(name string) string {
+		t.Helper()
+		rc, _, err := store.Get(context.Background(), name)
+		if err != nil {
+			t.Fatalf("%s %s", name, err)
+		}
+		defer rc.Close()
+		body, _ := io.ReadAll(rc)
+		return string(body)
+	}
+
+	var legislation []Legislation
+	body := get(fmt.Sprintf("build/%d.json", year))
+	if err := json.Unmarshal([]byte(body), &legislation); err != nil {
+		t.Fatal(err)
+	}
+	if len(legislation) != 2 || legislation[0].Name != "Bike Lanes" || len(legislation[0].History) != 3 {
+		t.Fatalf("unexpected %s", body)
+	}
+	for _, omitted := range []string{"GUID", "RTF", `"Text"`, `"Votes"`, `"ActionID"`} {
+		if strings.Contains(body, omitted) {
+			t.Errorf("build/%d.json contains %s", year, omitted)
+		}
+	}
+
+	var votes []VotesIndexRow
+	json.Unmarshal([]byte(get(fmt.Sprintf("build/%d_votes.json", year))), &votes)
+	if len(votes) != 2 || len(votes[0].History) != 1 || votes[0].History[0].ID != 11 || votes[0].History[0].Votes[0].VoteID != 15 {
+		t.Errorf("unexpected votes %#v", votes)
+	}
+
+	var search []SearchEntry
+	json.Unmarshal([]byte(get(searchIndexFile(CurrentSession, "introduction"))), &search)
+	if len(search) != 2 || !search[0].LastModified.Equal(time.Date(year, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected search index %#v", search)
+	}
+	get(searchIndexFile(CurrentSession, "resolution"))
+	get(fmt.Sprintf("build/resolution_%d.json", year))
+
+	if body := get(fmt.Sprintf("build/events_%d.json", year)); strings.Contains(body, "GUID") || !strings.Contains(body, `"VideoPath":"v"`) || strings.Contains(body, "RollCall\"") {
+		t.Errorf("unexpected events %s", body)
+	}
+	if body := get(fmt.Sprintf("build/events_attendance_%d.json", year)); strings.Contains(body, "Other") || strings.Contains(body, "FullName") || !strings.Contains(body, `"ValueID":13`) {
+		t.Errorf("unexpected attendance %s", body)
+	}
+
+	if body := get("build/people_active.json"); strings.Contains(body, "john-roe") || strings.Contains(body, "FirstName") || strings.Contains(body, "PersonID") {
+		t.Errorf("unexpected people_active %s", body)
+	}
+	if body := get("build/people_all.json"); !strings.Contains(body, "john-roe") {
+		t.Errorf("unexpected people_all %s", body)
+	}
+	if body := get("build/local_laws.json"); body != fmt.Sprintf(`[{"File":"Int 0001-%[1]d","LocalLaw":"%[1]d/001","Title":""}]`, year) {
+		t.Errorf("unexpected local_laws %s", body)
+	}
+	if body := get("build/legislation_jane-doe.json"); !strings.Contains(body, fmt.Sprintf("Int 0001-%d", year)) || strings.Contains(body, fmt.Sprintf("Int 0002-%d", year)) || strings.Contains(body, "Votes") {
+		t.Errorf("unexpected legislation_jane-doe %s", body)
+	}
+	if body := get("build/resolution_jane-doe.json"); !strings.Contains(body, fmt.Sprintf("Res 0001-%d", year)) {
+		t.Errorf("unexpected resolution_jane-doe %s", body)
+	}
+	if _, err := store.Stat(context.Background(), "build/legislation_john-roe.json"); !isNotExist(err) {
+		t.Errorf("expected no legislation for inactive council member got %v", err)
+	}
+	get("build/people_metadata.json")
+	get(fmt.Sprintf("build/resubmit_%d.json", year))
+	get("build/last_sync.json")
+}
+
+func TestIndexerEventSequence(t *testing.T) {
+	year := CurrentSession.StartYear
+	event := func(published, status, date string) []byte {
+		return []byte(fmt.Sprintf(`{"ID":1,"BodyName":"Committee on Transportation","AgendaLastPublished":"%[1]d-%[2]sT00:00:00Z","AgendaStatusName":%[3]q,"Date":"%[1]d-%[4]sT15:00:00Z"}`,
+			year, published, status, date))
+	}
+	source := fstest.MapFS{
+		fmt.Sprintf("events/%d/1.json", year): &fstest.MapFile{Data: event("02-01", "Final", "03-01")},
+	}
+	store := NewMemoryStore()
+	x := &Indexer{Source: source, Store: store, Now: time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC)}
+	sequence := func() int {
+		t.Helper()
+		var events []Event
+		if err := x.getPrevious(context.Background(), fmt.Sprintf("build/events_%d.json", year), &events); err != nil || len(events) != 1 {
+			t.Fatalf("unexpected events %#v %v", events, err)
+		}
+		return events[0].Sequence
+	}
+
+	for i, tc := range []struct {
+		event    []byte
+		expected int
+	}{
+		{nil, 0},
+		{nil, 0},
+		{event("02-05", "Final", "03-01"), 1}, // agenda republished
+		{nil, 1},
+		{event("02-05", "Deferred", "03-01"), 2}, // deferred without republishing the agenda
+		{event("02-05", "Deferred", "03-08"), 3}, // rescheduled
+		{nil, 3},
+	} {
+		if tc.event != nil {
+			source[fmt.Sprintf("events/%d/1.json", year)].Data = tc.event
+		}
+		if err := x.buildEvents(context.Background(), year); err != nil {
+			t.Fatal(err)
+		}
+		if got := sequence(); got != tc.expected {
+			t.Errorf("build %d got sequence %d expected %d", i, got, tc.expected)
+		}
+	}
+
+	// a fresh checkout carries the sequence forward from the published build
+	published := store
+	x.Store, x.Previous = NewMemoryStore(), published
+	source[fmt.Sprintf("events/%d/1.json", year)].Data = event("02-09", "Deferred", "03-08")
+	if err := x.buildEvents(context.Background(), year); err != nil {
+		t.Fatal(err)
+	}
+	x.Previous = x.Store
+	if got := sequence(); got != 4 {
+		t.Errorf("got sequence %d from published build expected 4", got)
+	}
+}
@@ -3,13 +3,13 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/jehiah/legislator/db"
-	"github.com/jehiah/legislator/legistar"
 )
 
 func (a *App) FileRedirect(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +23,10 @@ func (a *App) FileRedirect(w http.ResponseWriter, r *http.Request) {
 		a.IntroJSON(w, r, file)
 		return
 	}
+	if strings.HasSuffix(file, ".atom") && IsValidIntroID(strings.TrimSuffix(file, ".atom")) {
+		a.IntroFeed(w, r, file)
+		return
+	}
 	if strings.HasSuffix(file, "+") && IsValidIntroID(strings.TrimSuffix(file, "+")) {
 		a.IntroSummary(w, r)
 		return
@@ -47,28 +51,19 @@ func (a *App) IntroRedirect(w http.ResponseWriter, r *http.Request, s string) {
 		return
 	}
 
-	filter := legistar.AndFilters(
-		legistar.MatterTypeFilter(id.Type()),
-		legistar.MatterFileFilter(id.File()),
-	)
-
-	// TODO: retry with a suffix -A for older years
-	// i.e. Int 0804-1996-A
-
-	matters, err := a.legistar.Matters(r.Context(), filter)
+	matterID, err := a.getMatterID(r.Context(), id)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "unknown error", 500)
 		return
 	}
-	if len(matters) != 1 {
+	if matterID == 0 {
 		// TODO: cache?
 		http.Error(w, "Not Found", 404)
 		return
 	}
 
-	// we have one
-	redirect, err := a.legistar.LookupWebURL(r.Context(), matters[0].ID)
+	redirect, err := a.legistarWebURL(r.Context(), matterID)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "unknown error", 500)
@@ -95,6 +90,10 @@ func (a *App) IntroJSON(w http.ResponseWriter, r *http.Request, s string) {
 		http.Error(w, "unknown error", 500)
 		return
 	}
+	if l == nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
 
 	ttl := time.Hour
 	if l.IntroDate.Year() < CurrentSession.StartYear {
@@ -106,6 +105,83 @@ func (a *App) IntroJSON(w http.ResponseWriter, r *http.Request, s string) {
 	json.NewEncoder(w).Encode(l)
 }
 
+// IntroFeed returns an Atom feed of the history for File "Intro 1234-2020" at /1234-2020.atom
+func (a *App) IntroFeed(w http.ResponseWriter, r *http.Request, s string) {
+	s = strings.TrimSuffix(s, ".atom")
+	id, err := ParseIntroID(s)
+	if err != nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	l, err := a.GetLegislation(r.Context(), id)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "unknown error", 500)
+		return
+	}
+	if l == nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	link := "https://intro.nyc/" + string(id)
+	feed := AtomFeed{
+		ID:       atomID(l.IntroDate, string(id)),
+		Title:    fmt.Sprintf("%s: %s", l.File, l.Name),
+		Subtitle: l.Title,
+		Updated:  l.IntroDate,
+		Links: []AtomLink{
+			{Href: link + ".atom", Rel: "self", Type: "application/atom+xml"},
+			{Href: link + "+", Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for i := len(l.History) - 1; i >= 0; i-- {
+		feed.Entries = append(feed.Entries, newHistoryEntry(*l, History{l.History[i]}))
+	}
+
+	ttl := time.Hour
+	if l.IntroDate.Year() < CurrentSession.StartYear {
+		ttl = time.Hour * 48
+	}
+	a.addExpireHeaders(w, ttl)
+	a.writeAtom(w, feed)
+}
+
+// newHistoryEntry returns an Atom entry for a history action. The ID is
+// derived from the History ID and date so it's stable across syncs.
+func newHistoryEntry(l Legislation, h History) AtomEntry {
+	date := h.Date
+	updated := h.LastModified
+	if updated.IsZero() {
+		updated = h.Date
+	}
+	content := &strings.Builder{}
+	fmt.Fprintf(content, "%s %s on %s", l.File, h.Action, h.Date.In(americaNewYork).Format("January 2, 2006"))
+	if h.BodyName != "" {
+		fmt.Fprintf(content, " (%s)", h.BodyName)
+	}
+	fmt.Fprintf(content, "\n\n%s\n", l.Name)
+	if h.Description != "" {
+		fmt.Fprintf(content, "\n%s\n", h.Description)
+	}
+	if len(h.Votes) > 0 {
+		ayes, nays, abstains := h.getVotes()
+		fmt.Fprintf(content, "\nVotes: %d in favor, %d against, %d abstaining\n", ayes, nays, abstains)
+	}
+	return AtomEntry{
+		ID:         atomID(date, fmt.Sprintf("%s/history/%d", l.IntroID(), h.ID)),
+		Title:      fmt.Sprintf("%s %s", l.File, h.Action),
+		Updated:    updated,
+		Published:  &date,
+		Links:      []AtomLink{{Href: "https://intro.nyc" + string(l.IntroLink()) + "+", Rel: "alternate", Type: "text/html"}},
+		Categories: []AtomCategory{{Term: h.Action}},
+		Content:    &AtomText{Type: "text", Body: content.String()},
+	}
+}
+
+// GetLegislation returns the full record for id from the nyc_legislation archive
+// or the Legistar API. A nil result indicates id was not found.
 func (a *App) GetLegislation(ctx context.Context, id IntroID) (*Legislation, error) {
 
 	a.cacheMutex.RLock()
@@ -117,22 +193,38 @@ func (a *App) GetLegislation(ctx context.Context, id IntroID) (*Legislation, err
 	}
 	a.cacheMutex.RUnlock()
 
-	filter := legistar.AndFilters(
-		legistar.MatterTypeFilter(id.Type()),
-		legistar.MatterFileFilter(id.File()),
-	)
-
-	// TODO: retry with a suffix -A for older years
-	// i.e. Int 0804-1996-A
-	matters, err := a.legistar.Matters(ctx, filter)
+	v, err := a.getArchivedLegislation(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	if len(matters) != 1 {
+	if v == nil && !a.offline {
+		v, err = a.getLegistarLegislation(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
 		return nil, nil
 	}
 
-	l := db.NewLegislation(matters[0])
+	a.cacheMutex.Lock()
+	a.cachedLegislation[id] = &CachedLegislation{
+		Set:         time.Now(),
+		Legislation: v,
+	}
+	a.cacheMutex.Unlock()
+
+	return v, nil
+}
+
+// getLegistarLegislation builds the full record for id from the Legistar API
+func (a *App) getLegistarLegislation(ctx context.Context, id IntroID) (*Legislation, error) {
+	m, err := a.lookupMatter(ctx, id)
+	if err != nil || m == nil {
+		return nil, err
+	}
+
+	l := db.NewLegislation(*m)
 	sponsors, err := a.legistar.MatterSponsors(ctx, l.ID)
 	if err != nil {
 		return nil, err
@@ -182,16 +274,7 @@ func (a *App) GetLegislation(ctx context.Context, id IntroID) (*Legislation, err
 	l.Text = txt.SimplifiedText()
 	l.RTF = txt.SimplifiedRTF()
 
-	v := Legislation{l}
-
-	a.cacheMutex.Lock()
-	a.cachedLegislation[id] = &CachedLegislation{
-		Set:         time.Now(),
-		Legislation: &v,
-	}
-	a.cacheMutex.Unlock()
-
-	return &v, nil
+	return &Legislation{l}, nil
 }
 
 func (a *App) IntroSummary(w http.ResponseWriter, r *http.Request) {
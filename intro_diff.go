@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TextVersion is one version (i.e. "*", "A", "B") of the text of legislation
+type TextVersion struct {
+	TextID  int
+	Version string
+	Text    string `json:"-"`
+}
+
+type CachedTextVersions struct {
+	Set      time.Time
+	Versions []TextVersion
+}
+
+// GetTextVersions returns every text version of id, oldest first.
+//
+// In offline mode only the latest version (from the archive) is available.
+func (a *App) GetTextVersions(ctx context.Context, id IntroID) ([]TextVersion, error) {
+	a.cacheMutex.RLock()
+	if v, ok := a.cachedTextVersions[id]; ok && time.Since(v.Set) < time.Hour {
+		a.cacheMutex.RUnlock()
+		return v.Versions, nil
+	}
+	a.cacheMutex.RUnlock()
+
+	var o []TextVersion
+	if a.offline {
+		l, err := a.GetLegislation(ctx, id)
+		if err != nil || l == nil {
+			return nil, err
+		}
+		if l.Text != "" {
+			o = append(o, TextVersion{TextID: l.TextID, Version: l.Version, Text: l.Text})
+		}
+	} else {
+		matterID, err := a.getMatterID(ctx, id)
+		if err != nil || matterID == 0 {
+			return nil, err
+		}
+		versions, err := a.legistar.MatterTextVersions(ctx, matterID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			textID, _ := strconv.Atoi(v.TextID)
+			txt, err := a.legistar.MatterText(ctx, matterID, textID)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, TextVersion{TextID: textID, Version: v.Version, Text: txt.SimplifiedText()})
+		}
+	}
+
+	a.cacheMutex.Lock()
+	a.cachedTextVersions[id] = &CachedTextVersions{Set: time.Now(), Versions: o}
+	a.cacheMutex.Unlock()
+	return o, nil
+}
+
+// IntroDiff shows a word level diff between two text versions
+// URL: /1234-2020/diff?from=A&to=B or /1234-2020/diff.json?from=A&to=B
+//
+// from and to default to the last two versions; an unknown version is a 400
+func (a *App) IntroDiff(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	wantJSON := strings.HasSuffix(r.URL.Path, ".json")
+	id, err := ParseIntroID(r.PathValue("file"))
+	if err != nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	ctx := r.Context()
+
+	l, err := a.GetLegislation(ctx, id)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "unknown error", 500)
+		return
+	}
+	if l == nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	versions, err := a.GetTextVersions(ctx, id)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "unknown error", 500)
+		return
+	}
+	if len(versions) == 0 {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	type Page struct {
+		Page        string
+		SubPage     string
+		Legislation Legislation `json:"-"`
+		File        string
+		Versions    []TextVersion
+		From, To    TextVersion
+		Inserted    int
+		Deleted     int
+		Diff        []DiffChunk
+	}
+	body := Page{
+		Page:        "",
+		SubPage:     "",
+		Legislation: *l,
+		File:        l.File,
+		Versions:    versions,
+		From:        versions[max(0, len(versions)-2)],
+		To:          versions[len(versions)-1],
+	}
+	for _, param := range []struct {
+		name string
+		v    *TextVersion
+	}{{"from", &body.From}, {"to", &body.To}} {
+		version := r.Form.Get(param.name)
+		if version == "" {
+			continue
+		}
+		i := slices.IndexFunc(versions, func(v TextVersion) bool { return v.Version == version })
+		if i == -1 {
+			http.Error(w, "unknown version", 400)
+			return
+		}
+		*param.v = versions[i]
+	}
+	body.Diff = DiffWords(body.From.Text, body.To.Text)
+	body.Inserted, body.Deleted = diffStats(body.Diff)
+
+	ttl := time.Hour
+	if l.IntroDate.Year() < CurrentSession.StartYear {
+		ttl = time.Hour * 48
+	}
+	a.addExpireHeaders(w, ttl)
+
+	if wantJSON {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	template := "bill_diff.html"
+	t := newTemplate(a.templateFS, template)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "unknown error", 500)
+		return
+	}
+}
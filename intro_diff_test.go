@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIntroDiff(t *testing.T) {
+	app := newTestApp(t, nil)
+	app.offline = true
+	app.archive = NewMemoryStore()
+	app.archive.Put(context.Background(), "introduction/2024/0012.json", "", strings.NewReader(`{"File":"Int 0012-2024","Name":"Test","Version":"A","TextID":1,"Text":"bike lanes"}`))
+
+	for qs, code := range map[string]int{
+		"":             200,
+		"?from=A&to=A": 200,
+		"?from=Z":      400,
+		"?from=A&to=B": 400,
+		"?to=%2A":      400,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/0012-2024/diff.json"+qs, nil)
+		r.SetPathValue("file", "0012-2024")
+		app.IntroDiff(w, r)
+		if w.Code != code {
+			t.Errorf("%q got status %d expected %d %s", qs, w.Code, code, w.Body)
+		}
+	}
+}
@@ -14,12 +14,10 @@ import (
 	"net/url"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/gorilla/handlers"
 	"github.com/jehiah/legislator/legistar"
 )
@@ -40,15 +38,19 @@ type CachedLegislation struct {
 type App struct {
 	legistar      *legistar.Client
 	devMode       bool
-	gsclient      *storage.Client
-	devFilePath   string
+	offline       bool  // don't use the Legistar API
+	store         Store // build artifacts and cached files
+	archive       Store // nyc_legislation archive
 	staticHandler http.Handler
 	templateFS    fs.FS
-
-	cachedRedirects   map[IntroID]string
-	fileCache         map[string]CachedFile
-	cachedLegislation map[IntroID]*CachedLegislation
-	cacheMutex        sync.RWMutex
+	now           func() time.Time // defaults to time.Now
+
+	cachedRedirects    map[IntroID]string
+	fileCache          map[string]CachedFile
+	cachedLegislation  map[IntroID]*CachedLegislation
+	cachedTextVersions map[IntroID]*CachedTextVersions
+	searchIndexes      map[string]*cachedSearchIndex
+	cacheMutex         sync.RWMutex
 }
 
 type CachedFile struct {
@@ -56,6 +58,14 @@ type CachedFile struct {
 	Date time.Time
 }
 
+// Now returns the current time
+func (a *App) Now() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+	return time.Now()
+}
+
 // RobotsTXT renders /robots.txt
 func (a *App) RobotsTXT(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("content-type", "text/plain")
@@ -85,29 +95,14 @@ func (a *App) getFile(ctx context.Context, filename string) (io.Reader, error) {
 	}
 	a.cacheMutex.RUnlock()
 
-	var body []byte
-	if a.devMode && a.devFilePath != "" {
-		fp := filepath.Join(a.devFilePath, filename)
-		log.Printf("opening %s", fp)
-		f, err := os.Open(fp)
-		if err != nil {
-			return nil, err
-		}
-		defer f.Close()
-		body, err = io.ReadAll(f)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		log.Printf("get gs://intronyc/%s", filename)
-		r, err := a.gsclient.Bucket("intronyc").Object(filename).NewReader(ctx)
-		if err != nil {
-			return nil, err
-		}
-		body, err = io.ReadAll(r)
-		if err != nil {
-			return nil, err
-		}
+	rc, _, err := a.store.Get(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
 	}
 
 	a.cacheMutex.Lock()
@@ -124,7 +119,7 @@ func (a *App) addExpireHeaders(w http.ResponseWriter, duration time.Duration) {
 	w.Header().Add("Expires", time.Now().Add(duration).Format(http.TimeFormat))
 }
 
-// ProxyJSON proxies to /data/file.json to gs://intronyc/build/$file.json
+// ProxyJSON proxies to /data/file.json to $store/build/$file.json
 func (a *App) ProxyJSON(w http.ResponseWriter, r *http.Request) {
 	path := r.PathValue("path")
 
@@ -134,14 +129,17 @@ func (a *App) ProxyJSON(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cacheTTL := time.Minute * 15
-	switch path {
-	case "search_index_2022-2023.json", "search_index_2018-2021.json", "search_index_2024-2025.json":
-		cacheTTL = time.Hour * 24
+	// search indexes for past sessions rarely change
+	for _, s := range Sessions[1:] {
+		switch "build/" + path {
+		case searchIndexFile(s, "introduction"), searchIndexFile(s, "resolution"):
+			cacheTTL = time.Hour * 24
+		}
 	}
 
 	rc, err := a.getFile(r.Context(), fmt.Sprintf("build/%s", path))
 	if err != nil {
-		if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+		if isNotExist(err) {
 			a.addExpireHeaders(w, time.Minute*10)
 			http.Error(w, "Not Found", 404)
 			return
@@ -171,28 +169,55 @@ func redirect(p string) http.HandlerFunc {
 func main() {
 	logRequests := flag.Bool("log-requests", false, "log requests")
 	devMode := flag.Bool("dev-mode", false, "development mode")
-	devFilePath := flag.String("file-path", "", "path to files normally retrieved from gs://intronyc/")
+	devFilePath := flag.String("file-path", "", "path to files normally retrieved from gs://intronyc/ (overrides --store in --dev-mode)")
+	storeURI := flag.String("store", "gs://intronyc", "storage backend: gs://$bucket, s3://$bucket (endpoint from $S3_ENDPOINT), file:///$path or memory:")
+	archivePath := flag.String("archive", "", "path to a nyc_legislation checkout used before the Legistar API for legislation details")
+	offline := flag.Bool("offline", false, "don't call the Legistar API; serve legislation details only from --archive")
+	previousStore := flag.String("previous-store", "", "store with the last published build read by index (defaults to --store)")
+	sessionsPath := flag.String("sessions", "", "path to a sessions.json replacing the built in list of legislative sessions")
 	flag.Parse()
 
-	log.Print("starting server...")
+	if *sessionsPath != "" {
+		f, err := os.Open(*sessionsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = LoadSessions(f, time.Now())
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	client, err := storage.NewClient(context.Background())
+	if *devMode && *devFilePath != "" {
+		*storeURI = *devFilePath
+	}
+	store, err := NewStore(context.Background(), *storeURI)
 	if err != nil {
-		log.Fatalf("Failed to create client: %v", err)
+		log.Fatalf("Failed to create store: %v", err)
+	}
+	var archive Store
+	if *archivePath != "" {
+		archive, err = NewStore(context.Background(), *archivePath)
+		if err != nil {
+			log.Fatalf("Failed to open archive: %v", err)
+		}
 	}
-	defer client.Close()
 
 	app := &App{
 		legistar:      legistar.NewClient("nyc", os.Getenv("NYC_LEGISLATOR_TOKEN")),
-		gsclient:      client,
+		store:         store,
+		archive:       archive,
+		offline:       *offline,
 		devMode:       *devMode,
-		devFilePath:   *devFilePath,
 		staticHandler: http.FileServer(http.FS(static)),
 		templateFS:    content,
 
-		cachedRedirects:   make(map[IntroID]string),
-		cachedLegislation: make(map[IntroID]*CachedLegislation),
-		fileCache:         make(map[string]CachedFile),
+		cachedRedirects:    make(map[IntroID]string),
+		cachedLegislation:  make(map[IntroID]*CachedLegislation),
+		cachedTextVersions: make(map[IntroID]*CachedTextVersions),
+		searchIndexes:      make(map[string]*cachedSearchIndex),
+		fileCache:          make(map[string]CachedFile),
 	}
 	if *devMode {
 		app.templateFS = os.DirFS(".")
@@ -203,9 +228,65 @@ func main() {
 		panic(err)
 	}
 
+	switch flag.Arg(0) {
+	case "build-reports":
+		// intro.nyc build-reports [all | $session ...]
+		sessions, err := parseSessions(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := app.buildReports(context.Background(), sessions); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "index":
+		// intro.nyc --archive=../nyc_legislation --store=../nyc_legislation [--previous-store=gs://intronyc] index [all | $session ...]
+		if *archivePath == "" {
+			log.Fatal("index requires --archive")
+		}
+		sessions, err := parseSessions(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		indexer := &Indexer{Source: os.DirFS(strings.TrimPrefix(*archivePath, "file://")), Store: store}
+		if *previousStore != "" {
+			indexer.Previous, err = NewStore(context.Background(), *previousStore)
+			if err != nil {
+				log.Fatalf("Failed to open previous store: %v", err)
+			}
+		}
+		if err := indexer.Build(context.Background(), sessions); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "index-attachments":
+		// intro.nyc --archive=../nyc_legislation --store=gs://intronyc index-attachments [all | $session ...]
+		if *archivePath == "" {
+			log.Fatal("index-attachments requires --archive")
+		}
+		sessions, err := parseSessions(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		indexer := &Indexer{Source: os.DirFS(strings.TrimPrefix(*archivePath, "file://")), Store: store}
+		if err := indexer.BuildAttachments(context.Background(), sessions); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "":
+	default:
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
+
+	log.Print("starting server...")
+
 	fileRouter := http.NewServeMux()
 	fileRouter.HandleFunc("GET /{file}", app.FileRedirect)
 	fileRouter.HandleFunc("GET /{file}/local-law", app.LocalLaw)
+	fileRouter.HandleFunc("GET /{file}/diff", app.IntroDiff)
+	fileRouter.HandleFunc("GET /{file}/diff.json", app.IntroDiff)
+	fileRouter.HandleFunc("GET /{file}/attachments.json", app.Attachments)
+	fileRouter.HandleFunc("GET /{file}/attachments/{attachment}", app.Attachment)
 
 	router := http.NewServeMux()
 
@@ -216,26 +297,58 @@ func main() {
 	})
 	router.HandleFunc("GET /robots.txt", app.RobotsTXT)
 	router.HandleFunc("GET /recent", app.RecentLegislation)
+	router.HandleFunc("GET /recent.atom", app.RecentLegislation)
+	router.HandleFunc("GET /recent.json", app.RecentLegislation)
 	router.HandleFunc("GET /map", app.Map)
 	router.HandleFunc("GET /calendar", app.Events)
 	router.HandleFunc("GET /events", app.Events)
 	router.HandleFunc("GET /events.ics", app.Events)
+	router.HandleFunc("GET /events/{year}", app.EventsYear)
+	router.HandleFunc("GET /events/{year}/{id}", app.Event)
+	router.HandleFunc("GET /committees", app.Committees)
+	router.HandleFunc("GET /committees/{committee}", varyAccept(app.Committee))
 	router.HandleFunc("GET /councilmembers", app.Councilmembers)
 	router.HandleFunc("GET /councilmembers/{councilmember}", app.Councilmember)
+	router.HandleFunc("GET /councilmembers/{councilmember}/votes", varyAccept(app.CouncilmemberVotes))
+	router.HandleFunc("GET /councilmembers/{councilmember}/votes.json", app.CouncilmemberVotes)
+	router.HandleFunc("GET /votes/{file}/{id}", varyAccept(app.Votes))
 	router.HandleFunc("GET /local-laws", app.LocalLaws)
 	router.HandleFunc("GET /local-laws/{year}", app.LocalLaws)
 	router.HandleFunc("GET /data/{path}", app.ProxyJSON)
+	router.HandleFunc("GET /api/search", app.SearchAPI)
 	router.HandleFunc("GET /reports/", redirect("/reports/session")) // redirect -> /reports/session
 	router.Handle("GET /static/", app.staticHandler)
 
-	router.HandleFunc("GET /reports/most_sponsored", app.ReportMostSponsored)
+	// reports return JSON with an Accept: application/json header or a .json suffix; some have a .csv variant
+	router.HandleFunc("GET /reports/most_sponsored", varyAccept(app.ReportMostSponsored))
+	router.HandleFunc("GET /reports/most_sponsored.json", app.ReportMostSponsored)
+	router.HandleFunc("GET /reports/most_sponsored.csv", app.ReportMostSponsored)
 	router.HandleFunc("GET /reports/status", redirect("/reports/session")) // /reports/session
-	router.HandleFunc("GET /reports/session", app.ReportBySession)
-	router.HandleFunc("GET /reports/similarity", app.ReportSimilarity)
-	router.HandleFunc("GET /reports/councilmembers", app.ReportCouncilmembers)
-	router.HandleFunc("GET /reports/committees", app.ReportCommittees)
-	router.HandleFunc("GET /reports/attendance", app.ReportAttendance)
-	router.HandleFunc("GET /reports/reintroductions", app.ReportReintroductions)
+	router.HandleFunc("GET /reports/session", varyAccept(app.ReportBySession))
+	router.HandleFunc("GET /reports/session.json", app.ReportBySession)
+	router.HandleFunc("GET /reports/similarity", varyAccept(app.ReportSimilarity))
+	router.HandleFunc("GET /reports/similarity.json", app.ReportSimilarity)
+	router.HandleFunc("GET /reports/agreement", varyAccept(app.ReportAgreement))
+	router.HandleFunc("GET /reports/agreement.json", app.ReportAgreement)
+	router.HandleFunc("GET /reports/stalled", varyAccept(app.ReportStalled))
+	router.HandleFunc("GET /reports/stalled.json", app.ReportStalled)
+	router.HandleFunc("GET /reports/stalled.csv", app.ReportStalled)
+	router.HandleFunc("GET /reports/stalled.atom", app.ReportStalled)
+	router.HandleFunc("GET /reports/lifecycle", varyAccept(app.ReportLifecycle))
+	router.HandleFunc("GET /reports/lifecycle.json", app.ReportLifecycle)
+	router.HandleFunc("GET /reports/lifecycle.csv", app.ReportLifecycle)
+	router.HandleFunc("GET /reports/councilmembers", varyAccept(app.ReportCouncilmembers))
+	router.HandleFunc("GET /reports/councilmembers.json", app.ReportCouncilmembers)
+	router.HandleFunc("GET /reports/councilmembers.csv", app.ReportCouncilmembers)
+	router.HandleFunc("GET /reports/committees", varyAccept(app.ReportCommittees))
+	router.HandleFunc("GET /reports/committees.json", app.ReportCommittees)
+	router.HandleFunc("GET /reports/committees.csv", app.ReportCommittees)
+	router.HandleFunc("GET /reports/attendance", varyAccept(app.ReportAttendance))
+	router.HandleFunc("GET /reports/attendance.json", app.ReportAttendance)
+	router.HandleFunc("GET /reports/attendance.csv", app.ReportAttendance)
+	router.HandleFunc("GET /reports/reintroductions", varyAccept(app.ReportReintroductions))
+	router.HandleFunc("GET /reports/reintroductions.json", app.ReportReintroductions)
+	router.HandleFunc("GET /reports/reintroductions.csv", app.ReportReintroductions)
 	router.HandleFunc("GET /reports/resubmit", redirect("/reports/reintroductions"))
 
 	router.Handle("/", fileRouter)
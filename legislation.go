@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"html/template"
+	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/jehiah/legislator/db"
@@ -11,6 +14,83 @@ import (
 
 type LegislationList []Legislation
 
+// IntroTypes returns the legislation types for a "type" parameter of
+// introduction (the default), resolution or all
+func IntroTypes(t string) ([]string, error) {
+	switch t {
+	case "", "introduction":
+		return []string{"introduction"}, nil
+	case "resolution":
+		return []string{"resolution"}, nil
+	case "all":
+		return []string{"introduction", "resolution"}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", t)
+}
+
+// introTypeParam returns the "type" form value (default introduction) and the legislation types it includes
+func introTypeParam(r *http.Request) (string, []string, error) {
+	t := r.Form.Get("type")
+	if t == "" {
+		t = "introduction"
+	}
+	introTypes, err := IntroTypes(t)
+	return t, introTypes, err
+}
+
+// legislationFile returns the build file for legislation of introType (introduction or resolution) from year
+//
+// suffix is "" or "_votes"
+func legislationFile(introType string, year int, suffix string) string {
+	if introType == "resolution" {
+		return fmt.Sprintf("build/resolution_%d%s.json", year, suffix)
+	}
+	return fmt.Sprintf("build/%d%s.json", year, suffix)
+}
+
+// getSessionLegislation returns the legislation of introTypes from all the years of a session
+//
+// suffix is "" or "_votes"
+func (a *App) getSessionLegislation(ctx context.Context, s Session, introTypes []string, suffix string) (LegislationList, error) {
+	var o LegislationList
+	for _, introType := range introTypes {
+		for year := s.StartYear; year <= s.EndYear && year <= time.Now().Year(); year++ {
+			var l []Legislation
+			err := a.getJSONFile(ctx, legislationFile(introType, year, suffix), &l)
+			if err != nil {
+				if isNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			o = append(o, l...)
+		}
+	}
+	return o, nil
+}
+
+// getSponsorLegislation returns the current session legislation of introTypes sponsored by a council member
+func (a *App) getSponsorLegislation(ctx context.Context, slug string, introTypes []string) (LegislationList, error) {
+	var o LegislationList
+	for _, introType := range introTypes {
+		filename := fmt.Sprintf("build/legislation_%s.json", slug)
+		if introType == "resolution" {
+			filename = fmt.Sprintf("build/resolution_%s.json", slug)
+		}
+		var l LegislationList
+		err := a.getJSONFile(ctx, filename, &l)
+		if err != nil {
+			// not found is ok; it means they are likely not active in current session (yet?)
+			if isNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		o = append(o, l...)
+	}
+	return o, nil
+}
+
 type Legislation struct {
 	db.Legislation
 }
@@ -35,6 +115,10 @@ func (ll Legislation) Session() Session {
 	return FindSession(ll.FileYear())
 }
 
+func (ll Legislation) IsResolution() bool {
+	return ll.IntroID().Type() == "Resolution"
+}
+
 func (ll Legislation) IntroLink() template.URL {
 	return template.URL("/" + string(ll.IntroID()))
 }
@@ -58,6 +142,16 @@ func (ll Legislation) SponsoredBy(id int) bool {
 	}
 	return false
 }
+
+// SponsoredBySlug returns true if the council member with slug is a sponsor
+func (ll Legislation) SponsoredBySlug(slug string) bool {
+	for _, s := range ll.Sponsors {
+		if s.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
 func (ll Legislation) Hearings() []db.History {
 	var o []db.History
 	for _, h := range ll.History {
@@ -95,7 +189,7 @@ func (h History) VoteSummary() string {
 
 func (h History) getVotes() (ayes int, nays int, abstains int) {
 	for _, v := range h.Votes {
-		switch v.Vote {
+		switch voteResult(v) {
 		case "Affirmative":
 			ayes++
 		case "Negative":
@@ -107,6 +201,21 @@ func (h History) getVotes() (ayes int, nays int, abstains int) {
 	return
 }
 
+// voteResult classifies a vote as "Affirmative", "Negative" or "Abstain" ("" when absent, excused, etc)
+//
+// build/$year_votes.json only has the VoteID
+func voteResult(v db.Vote) string {
+	switch {
+	case v.Vote == "Affirmative" || v.VoteID == 15:
+		return "Affirmative"
+	case v.Vote == "Negative" || v.VoteID == 12:
+		return "Negative"
+	case v.Vote == "Abstain" || v.VoteID == 11:
+		return "Abstain"
+	}
+	return ""
+}
+
 func (ll Legislation) RecentAction() (string, time.Time) {
 	// walk in reverse
 	for i := len(ll.History) - 1; i >= 0; i-- {
@@ -138,6 +247,36 @@ func (l LegislationList) Number() int {
 	return len(l)
 }
 
+// Enacted returns the legislation that has been enacted (or is awaiting the Mayor's signature)
+func (l LegislationList) Enacted() LegislationList {
+	var o []Legislation
+	for _, ll := range l {
+		if strings.HasPrefix(ll.StatusName, "Enacted") {
+			o = append(o, ll)
+		}
+	}
+	return LegislationList(o)
+}
+
+// EnactedPercent is the percent (0-100) of legislation that has been enacted
+func (l LegislationList) EnactedPercent() float64 {
+	if len(l) == 0 {
+		return 0
+	}
+	return float64(len(l.Enacted())) / float64(len(l)) * 100
+}
+
+// FilterType limits the list to introType (introduction or resolution)
+func (l LegislationList) FilterType(introType string) LegislationList {
+	var o []Legislation
+	for _, ll := range l {
+		if ll.IsResolution() == (introType == "resolution") {
+			o = append(o, ll)
+		}
+	}
+	return LegislationList(o)
+}
+
 func (l LegislationList) FilterPrimarySponsor(sponsor int) LegislationList {
 	var o []Legislation
 	for _, ll := range l {
@@ -1,19 +1,20 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
 	"net/http"
-	"path/filepath"
+	"path"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
+	"github.com/jehiah/legislator/db"
 	"github.com/jehiah/legislator/legistar"
 )
 
@@ -159,6 +160,49 @@ func (a *App) LocalLaws(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getLocalLawAttachments returns the Legistar Matter ID and attachments for Local Law n of year
+//
+// A matter ID of 0 indicates the local law was not found.
+func (a *App) getLocalLawAttachments(ctx context.Context, year string, n int) (int, []db.Attachment, error) {
+	var laws []LocalLaw
+	err := a.getJSONFile(ctx, "build/local_laws.json", &laws)
+	if err != nil && !isNotExist(err) {
+		return 0, nil, err
+	}
+	for _, ll := range laws {
+		if strconv.Itoa(ll.Year()) != year || ll.LocalLawNumber() != n {
+			continue
+		}
+		if id, err := ParseFile(ll.File); err == nil {
+			return a.getAttachments(ctx, id)
+		}
+	}
+	if a.offline {
+		return 0, nil, nil
+	}
+
+	filter := legistar.AndFilters(
+		legistar.MatterTypeFilter("Introduction"),
+		legistar.MatterEnactmentNumberFilter(fmt.Sprintf("%s/%03d", year, n)),
+	)
+	matters, err := a.legistar.Matters(ctx, filter)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(matters) != 1 {
+		return 0, nil, nil
+	}
+	attachments, err := a.legistar.MatterAttachments(ctx, matters[0].ID)
+	if err != nil {
+		return 0, nil, err
+	}
+	var o []db.Attachment
+	for _, attachment := range attachments {
+		o = append(o, db.NewAttachment(attachment))
+	}
+	return matters[0].ID, o, nil
+}
+
 // LocalLawPDF redirects to the attachment with name "Local Law ..."
 func (a *App) LocalLawPDF(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -167,72 +211,45 @@ func (a *App) LocalLawPDF(w http.ResponseWriter, r *http.Request) {
 
 	filename := fmt.Sprintf("local_law_%d_of_%s.pdf", n, year)
 
-	// first check google storage
-	bucketfile := filepath.Join("local_laws", filename)
-	log.Printf("checking gs://intronyc/%s", bucketfile)
-	pdfReader, err := a.gsclient.Bucket("intronyc").Object(bucketfile).NewReader(ctx)
-	if err == storage.ErrObjectNotExist {
-		err = nil
-	}
-	if err != nil {
+	// first check the store
+	storefile := path.Join("local_laws", filename)
+	pdfReader, attrs, err := a.store.Get(ctx, storefile)
+	if err != nil && !isNotExist(err) {
 		log.Print(err)
-		// http.Error(w, "unknown error", 500)
-		// return
-	} else {
-		if pdfReader != nil {
-			log.Printf("returning gs://intronyc/%s", bucketfile)
-			defer pdfReader.Close()
-
-			// handle 304
-			if r.Header.Get("if-modified-since") == pdfReader.Attrs.LastModified.Format(http.TimeFormat) {
-				w.WriteHeader(304)
-				return
-			}
+	} else if err == nil {
+		log.Printf("returning %s", storefile)
+		defer pdfReader.Close()
 
-			w.Header().Set("content-type", "application/pdf")
-			a.addExpireHeaders(w, time.Hour*24*7)
-			w.Header().Set("content-disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
-			w.Header().Set("content-length", fmt.Sprintf("%d", pdfReader.Attrs.Size))
-			w.Header().Set("last-modified", pdfReader.Attrs.LastModified.Format(http.TimeFormat))
-			io.Copy(w, pdfReader)
+		// handle 304
+		if r.Header.Get("if-modified-since") == attrs.LastModified.Format(http.TimeFormat) {
+			w.WriteHeader(304)
 			return
 		}
-	}
 
-	filter := legistar.AndFilters(
-		legistar.MatterTypeFilter("Introduction"),
-		legistar.MatterEnactmentNumberFilter(fmt.Sprintf("%s/%03d", year, n)),
-	)
+		w.Header().Set("content-type", "application/pdf")
+		a.addExpireHeaders(w, time.Hour*24*7)
+		w.Header().Set("content-disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+		w.Header().Set("content-length", fmt.Sprintf("%d", attrs.Size))
+		w.Header().Set("last-modified", attrs.LastModified.Format(http.TimeFormat))
+		io.Copy(w, pdfReader)
+		return
+	}
 
-	matters, err := a.legistar.Matters(ctx, filter)
+	matterID, attachments, err := a.getLocalLawAttachments(ctx, year, n)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "unknown error", 500)
 		return
 	}
-	if len(matters) != 1 {
+	if matterID == 0 {
 		// TODO: cache?
 		http.Error(w, "Not Found", 404)
 		return
 	}
 
-	attachments, err := a.legistar.MatterAttachments(ctx, matters[0].ID)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "unknown error", 500)
-		return
-	}
 	for _, attachment := range attachments {
 		if strings.HasPrefix(attachment.Name, "Local Law") {
 			// fetch it and cache it
-			pdfWriter := a.gsclient.Bucket("intronyc").Object(bucketfile).NewWriter(ctx)
-			pdfWriter.ContentType = "application/pdf"
-			// if err != nil {
-			// 	log.Print(err)
-			// 	http.Error(w, "unknown error", 500)
-			// 	return
-			// }
-			defer pdfWriter.Close()
 			log.Printf("downloading %s", attachment.Link)
 			req, err := http.NewRequestWithContext(ctx, "GET", attachment.Link, nil)
 			if err != nil {
@@ -253,17 +270,20 @@ func (a *App) LocalLawPDF(w http.ResponseWriter, r *http.Request) {
 				http.Error(w, "unknown error", 500)
 				return
 			}
-			// copy resp.Body to pdfWriter and w
+			// copy resp.Body to the store and w
 			w.Header().Set("content-type", "application/pdf")
 			a.addExpireHeaders(w, time.Hour*24*7)
 			w.Header().Set("content-disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
-			io.Copy(io.MultiWriter(pdfWriter, w), resp.Body)
+			err = a.store.Put(ctx, storefile, "application/pdf", io.TeeReader(resp.Body, w))
+			if err != nil {
+				log.Print(err)
+			}
 			return
 		}
 	}
 
 	// no attachment - redirect to the legislation page
-	redirect, err := a.legistar.LookupWebURL(r.Context(), matters[0].ID)
+	redirect, err := a.legistarWebURL(r.Context(), matterID)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "unknown error", 500)
@@ -287,25 +307,7 @@ func (a *App) LocalLaw(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Not Found", 404)
 		return
 	}
-	file = fmt.Sprintf("Int %s", file)
-
-	filter := legistar.AndFilters(
-		legistar.MatterTypeFilter("Introduction"),
-		legistar.MatterFileFilter(file),
-	)
-
-	matters, err := a.legistar.Matters(ctx, filter)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "unknown error", 500)
-		return
-	}
-	if len(matters) != 1 {
-		// TODO: cache?
-		http.Error(w, "Not Found", 404)
-		return
-	}
-	attachments, err := a.legistar.MatterAttachments(ctx, matters[0].ID)
+	_, attachments, err := a.getAttachments(ctx, IntroID(file))
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "unknown error", 500)
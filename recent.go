@@ -5,13 +5,12 @@ import (
 	"html/template"
 	"log"
 	"net/http"
-	"os"
+	"net/url"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
+	"github.com/gosimple/slug"
 	"github.com/jehiah/legislator/db"
 )
 
@@ -26,20 +25,20 @@ type RecentLegislation struct {
 	NumberSponsors int
 }
 
+func (r RecentLegislation) IntroID() IntroID {
+	i, _ := ParseFile(r.File)
+	return i
+}
+
 func (r RecentLegislation) Number() int {
-	c := strings.Split(strings.TrimPrefix(r.File, "Int "), "-")
-	if len(c) == 2 {
-		n, _ := strconv.Atoi(c[0])
-		return n
-	}
-	return 0
+	return r.IntroID().FileNumber()
 }
 
 func (l RecentLegislation) IntroLink() template.URL {
-	return template.URL("/" + strings.TrimPrefix(l.File, "Int "))
+	return template.URL("/" + string(l.IntroID()))
 }
 func (l RecentLegislation) IntroLinkText() string {
-	return "intro.nyc/" + strings.TrimPrefix(l.File, "Int ")
+	return "intro.nyc" + string(l.IntroLink())
 }
 
 func NewRecentLegislation(l Legislation) RecentLegislation {
@@ -92,7 +91,15 @@ func NewDateGroups(r []RecentLegislation) []DateGroup {
 }
 
 // RecentLegislation returns the list of legislation changes /recent
+//
+// /recent.atom and /recent.json (JSON Feed) return the same changes as a feed
+// and can be filtered by committee=slug and action=slug (i.e. action=introduced)
+//
+// type=resolution or type=all include resolutions
 func (a *App) RecentLegislation(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	wantAtom := strings.HasSuffix(r.URL.Path, ".atom")
+	wantJSON := strings.HasSuffix(r.URL.Path, ".json")
 
 	t := newTemplate(a.templateFS, "recent_legislation.html")
 
@@ -101,36 +108,34 @@ func (a *App) RecentLegislation(w http.ResponseWriter, r *http.Request) {
 		LastSync       LastSync
 		Dates          []DateGroup
 		ResubmitLookup map[string]*Legislation
+		IntroType      string
 	}
 	body := Page{
 		Page:           "recent",
 		ResubmitLookup: make(map[string]*Legislation),
 	}
+	var introTypes []string
+	var err error
+	body.IntroType, introTypes, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
 
-	var legislation LegislationList
-
-	// get all the years for the legislative session
-	for year := CurrentSession.StartYear; year <= CurrentSession.EndYear && year <= time.Now().Year(); year++ {
-		var l []Legislation
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
-		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
-				continue
-			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
-		}
-		legislation = append(legislation, l...)
+	legislation, err := a.getSessionLegislation(r.Context(), CurrentSession, introTypes, "")
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
 	}
-	body.Dates = NewDateGroups(legislation.Recent(time.Hour * 24 * 30))
+	recent := filterRecent(legislation.Recent(time.Hour*24*30), r.Form.Get("committee"), r.Form.Get("action"))
 
 	// build a lookup of re-submit bills
 	for year := CurrentSession.StartYear; year <= CurrentSession.EndYear && year <= time.Now().Year(); year++ {
 		var resubmitFile ResubmitFile
 		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/resubmit_%d.json", year), &resubmitFile)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
 			log.Print(err)
@@ -144,7 +149,20 @@ func (a *App) RecentLegislation(w http.ResponseWriter, r *http.Request) {
 
 	cacheTTL := time.Minute * 30
 
-	err := a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if wantAtom || wantJSON {
+		a.addExpireHeaders(w, cacheTTL)
+		items := newRecentFeedItems(recent, body.ResubmitLookup)
+		feedURL := (&url.URL{Scheme: "https", Host: "intro.nyc", Path: r.URL.Path, RawQuery: r.Form.Encode()}).String()
+		if wantJSON {
+			a.writeJSONFeed(w, recentJSONFeed(feedURL, items))
+		} else {
+			a.writeAtom(w, recentAtomFeed(feedURL, items))
+		}
+		return
+	}
+	body.Dates = NewDateGroups(recent)
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "Internal Server Error", 500)
@@ -160,3 +178,136 @@ func (a *App) RecentLegislation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// filterRecent limits r to changes in a committee (slug) and/or actions with
+// a slug starting with action (i.e. "introduced" matches "Introduced by Council")
+func filterRecent(r []RecentLegislation, committee, action string) []RecentLegislation {
+	committee, action = slug.Make(committee), slug.Make(action)
+	if committee == "" && action == "" {
+		return r
+	}
+	var o []RecentLegislation
+	for _, rr := range r {
+		if committee != "" && slug.Make(TrimCommittee(rr.BodyName)) != committee {
+			continue
+		}
+		if action != "" && !strings.HasPrefix(slug.Make(rr.Action), action) {
+			continue
+		}
+		o = append(o, rr)
+	}
+	return o
+}
+
+// RecentFeedItem is the intro.nyc specific data included in each item of /recent.json
+type RecentFeedItem struct {
+	File            string
+	Name            string
+	Action          string
+	Date            time.Time
+	StatusName      string
+	BodyName        string
+	PrimarySponsor  string `json:",omitempty"`
+	NumberSponsors  int
+	ResubmittedFrom string `json:",omitempty"`
+
+	id  string
+	url string
+}
+
+func newRecentFeedItems(r []RecentLegislation, resubmitLookup map[string]*Legislation) []RecentFeedItem {
+	var o []RecentFeedItem
+	// newest first
+	for i := len(r) - 1; i >= 0; i-- {
+		rr := r[i]
+		item := RecentFeedItem{
+			File:           rr.File,
+			Name:           rr.Name,
+			Action:         rr.Action,
+			Date:           rr.Date,
+			StatusName:     rr.StatusName,
+			BodyName:       rr.BodyName,
+			NumberSponsors: rr.NumberSponsors,
+			id:             atomID(rr.Date, string(rr.IntroLink())[1:]+"/"+slug.Make(rr.Action)),
+			url:            "https://intro.nyc" + string(rr.IntroLink()),
+		}
+		if rr.NumberSponsors > 0 {
+			item.PrimarySponsor = rr.PrimarySponsor.FullName
+		}
+		if rr.Action == "Introduced by Council" {
+			if from, ok := resubmitLookup[rr.File]; ok {
+				item.ResubmittedFrom = from.File
+			}
+		}
+		o = append(o, item)
+	}
+	return o
+}
+
+func (r RecentFeedItem) Title() string {
+	return fmt.Sprintf("%s %s: %s", r.File, r.Action, r.Name)
+}
+
+func (r RecentFeedItem) Text() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s %s on %s\n\n%s\n", r.File, r.Action, r.Date.In(americaNewYork).Format("January 2, 2006"), r.Name)
+	if r.PrimarySponsor != "" {
+		fmt.Fprintf(b, "\nSponsored by %s", r.PrimarySponsor)
+		if r.NumberSponsors > 1 {
+			fmt.Fprintf(b, " with %d sponsors", r.NumberSponsors)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "Status: %s, %s\n", r.StatusName, r.BodyName)
+	if r.ResubmittedFrom != "" {
+		fmt.Fprintf(b, "Re-introduction of %s\n", r.ResubmittedFrom)
+	}
+	return b.String()
+}
+
+const recentFeedTitle = "NYC Council Recent Legislation Changes"
+
+func recentAtomFeed(feedURL string, items []RecentFeedItem) AtomFeed {
+	feed := AtomFeed{
+		ID:    feedURL,
+		Title: recentFeedTitle,
+		Links: []AtomLink{
+			{Href: feedURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: "https://intro.nyc/recent", Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, i := range items {
+		date := i.Date
+		feed.Entries = append(feed.Entries, AtomEntry{
+			ID:         i.id,
+			Title:      i.Title(),
+			Updated:    i.Date,
+			Published:  &date,
+			Links:      []AtomLink{{Href: i.url, Rel: "alternate", Type: "text/html"}},
+			Categories: []AtomCategory{{Term: i.Action}, {Term: i.StatusName}},
+			Content:    &AtomText{Type: "text", Body: i.Text()},
+		})
+	}
+	return feed
+}
+
+func recentJSONFeed(feedURL string, items []RecentFeedItem) JSONFeed {
+	feed := JSONFeed{
+		Title:       recentFeedTitle,
+		HomePageURL: "https://intro.nyc/recent",
+		FeedURL:     feedURL,
+		Items:       []JSONFeedItem{},
+	}
+	for _, i := range items {
+		feed.Items = append(feed.Items, JSONFeedItem{
+			ID:            i.id,
+			URL:           i.url,
+			Title:         i.Title(),
+			ContentText:   i.Text(),
+			DatePublished: i.Date,
+			Tags:          []string{i.Action, i.StatusName},
+			IntroNYC:      i,
+		})
+	}
+	return feed
+}
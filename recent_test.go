@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterRecent(t *testing.T) {
+	r := []RecentLegislation{
+		{File: "Int 0001-2024", Action: "Introduced by Council", BodyName: "Committee on Transportation and Infrastructure"},
+		{File: "Int 0002-2024", Action: "Hearing Held by Committee", BodyName: "Committee on Transportation and Infrastructure"},
+		{File: "Int 0003-2024", Action: "Introduced by Council", BodyName: "Committee on Housing and Buildings"},
+	}
+	type testCase struct {
+		committee, action string
+		expect            []string
+	}
+	for _, tc := range []testCase{
+		{"", "", []string{"Int 0001-2024", "Int 0002-2024", "Int 0003-2024"}},
+		{"transportation-and-infrastructure", "", []string{"Int 0001-2024", "Int 0002-2024"}},
+		{"", "introduced", []string{"Int 0001-2024", "Int 0003-2024"}},
+		{"transportation-and-infrastructure", "Hearing Held", []string{"Int 0002-2024"}},
+	} {
+		got := filterRecent(r, tc.committee, tc.action)
+		var files []string
+		for _, g := range got {
+			files = append(files, g.File)
+		}
+		if len(files) != len(tc.expect) {
+			t.Errorf("filterRecent(%q, %q) = %v expected %v", tc.committee, tc.action, files, tc.expect)
+			continue
+		}
+		for i := range files {
+			if files[i] != tc.expect[i] {
+				t.Errorf("filterRecent(%q, %q) = %v expected %v", tc.committee, tc.action, files, tc.expect)
+			}
+		}
+	}
+}
+
+func TestRecentFeedItems(t *testing.T) {
+	date := time.Date(2024, 2, 8, 18, 0, 0, 0, time.UTC)
+	r := []RecentLegislation{{File: "Int 0002-2024", Name: "Bike lanes", Action: "Introduced by Council", Date: date, NumberSponsors: 3}}
+	r[0].PrimarySponsor.FullName = "Jane Doe"
+	items := newRecentFeedItems(r, map[string]*Legislation{"Int 0002-2024": {}})
+	if len(items) != 1 {
+		t.Fatalf("got %d items", len(items))
+	}
+	if items[0].id != "tag:intro.nyc,2024-02-08:0002-2024/introduced-by-council" {
+		t.Errorf("unexpected id %q", items[0].id)
+	}
+	if items[0].url != "https://intro.nyc/0002-2024" || items[0].PrimarySponsor != "Jane Doe" {
+		t.Errorf("unexpected item %#v", items[0])
+	}
+}
+
+func TestRecentLegislationType(t *testing.T) {
+	year := CurrentSession.StartYear
+	recent := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json":                    `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		legislationFile("introduction", year, ""): `[{"File":"Int 0001-2026","Name":"Bike Lanes","History":[{"Action":"Introduced by Council","Date":"` + recent + `"}]}]`,
+		legislationFile("resolution", year, ""):   `[{"File":"Res 0002-2026","Name":"Calling on","History":[{"Action":"Introduced by Council","Date":"` + recent + `"}]}]`,
+	})
+	type testCase struct {
+		introType string
+		expect    []string
+		code      int
+	}
+	for _, tc := range []testCase{
+		{"", []string{"Int 0001-2026"}, 200},
+		{"resolution", []string{"Res 0002-2026"}, 200},
+		{"all", []string{"Int 0001-2026", "Res 0002-2026"}, 200},
+		{"other", nil, 400},
+	} {
+		w := httptest.NewRecorder()
+		app.RecentLegislation(w, httptest.NewRequest("GET", "/recent.json?type="+tc.introType, nil))
+		if w.Code != tc.code {
+			t.Errorf("type=%s got status %d", tc.introType, w.Code)
+			continue
+		}
+		if tc.code != 200 {
+			continue
+		}
+		var feed JSONFeed
+		if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+			t.Fatal(err)
+		}
+		var urls []string
+		for _, i := range feed.Items {
+			urls = append(urls, i.URL)
+		}
+		sort.Strings(urls)
+		var expect []string
+		for _, f := range tc.expect {
+			i, _ := ParseFile(f)
+			expect = append(expect, "https://intro.nyc/"+string(i))
+		}
+		if strings.Join(urls, ",") != strings.Join(expect, ",") {
+			t.Errorf("type=%s got %v expected %v", tc.introType, urls, expect)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ReportAgreement shows how often every pair of council members vote together and co-sponsor
+// legislation as a heatmap ordered into voting blocs at /reports/agreement
+func (a *App) ReportAgreement(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	template := "report_agreement.html"
+
+	t := newTemplate(a.templateFS, template)
+
+	type Page struct {
+		Page      string
+		SubPage   string
+		LastSync  LastSync
+		Session   Session
+		Sessions  []Session
+		Data      AgreementReport
+		IntroType string
+	}
+	body := Page{
+		Page:     "reports",
+		SubPage:  "agreement",
+		Session:  CurrentSession,
+		Sessions: Sessions,
+	}
+	for _, s := range Sessions {
+		if s.String() == r.Form.Get("session") {
+			body.Session = s
+		}
+	}
+	var err error
+	body.IntroType, _, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
+
+	body.Data, err = a.getAgreementReport(r.Context(), body.Session, body.IntroType)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if len(body.Data.Councilmembers) == 0 {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	cacheTTL := time.Minute * 15
+	if !body.Session.IsCurrent() {
+		cacheTTL = time.Hour * 24
+	}
+	if wantsJSON(r) {
+		a.writeReportJSON(w, cacheTTL, ReportResponse{
+			Report:   "agreement",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  withIntroType(nil, body.IntroType),
+			Data:     body.Data,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// getAgreementReport returns the snapshot for a session; reports including resolutions are always computed
+func (a *App) getAgreementReport(ctx context.Context, s Session, introType string) (AgreementReport, error) {
+	var report AgreementReport
+	if introType == "introduction" {
+		if ok, err := a.getReportSnapshot(ctx, "agreement", s, &report); ok || err != nil {
+			return report, err
+		}
+	}
+	reports, err := a.getSimilarityReports(ctx, s, introType)
+	if err != nil {
+		return report, err
+	}
+	return NewAgreementReport(reports), nil
+}
+
+// NewAgreementReport builds the N×N agreement matrix from the similarity report for each council member
+//
+// Vote agreement pools the votes compared in both directions so the matrix is symmetric.
+func NewAgreementReport(reports []SimilarityReport) AgreementReport {
+	n := len(reports)
+	index := make(map[int]int, n) // person ID -> reports index
+	for i, r := range reports {
+		index[r.Councilmember.ID] = i
+	}
+	members := make([]AgreementMember, n)
+	sponsors, votes := floatMatrix(n), floatMatrix(n)
+	expected := make([][]int, n)
+	for i, r := range reports {
+		members[i] = AgreementMember{Councilmember: r.Councilmember}
+		expected[i] = make([]int, n)
+		for _, row := range r.Rows {
+			j, ok := index[row.Councilmember.ID]
+			if !ok {
+				continue
+			}
+			if i == j {
+				members[i].Party = row.Party
+			}
+			// row.ExpectedSponsors is the number of bills sponsored by i
+			// row.Sponsors the number of those also sponsored by j
+			either := row.ExpectedSponsors + reports[j].sponsored() - row.Sponsors
+			if either > 0 {
+				sponsors[i][j] = (float64(row.Sponsors) / float64(either)) * 100
+			}
+		}
+	}
+	for i, r := range reports {
+		for _, row := range r.Rows {
+			j, ok := index[row.Councilmember.ID]
+			if !ok || j < i {
+				continue
+			}
+			reverse := reports[j].row(r.Councilmember.ID)
+			agree, compared := row.Votes+reverse.Votes, row.ExpectedVotes+reverse.ExpectedVotes
+			if compared > 0 {
+				votes[i][j] = (float64(agree) / float64(compared)) * 100
+				votes[j][i] = votes[i][j]
+			}
+			expected[i][j] = max(row.ExpectedVotes, reverse.ExpectedVotes)
+			expected[j][i] = expected[i][j]
+		}
+	}
+
+	order, blocs, merges := clusterAgreement(votes, expected)
+	report := AgreementReport{
+		Councilmembers: []AgreementMember{},
+		Sponsors:       [][]float64{},
+		Votes:          [][]float64{},
+		ExpectedVotes:  [][]int{},
+		Merges:         merges,
+	}
+	for _, i := range order {
+		m := members[i]
+		m.Bloc = blocs[i]
+		report.Councilmembers = append(report.Councilmembers, m)
+		var s, v []float64
+		var e []int
+		for _, j := range order {
+			s = append(s, sponsors[i][j])
+			v = append(v, votes[i][j])
+			e = append(e, expected[i][j])
+		}
+		report.Sponsors = append(report.Sponsors, s)
+		report.Votes = append(report.Votes, v)
+		report.ExpectedVotes = append(report.ExpectedVotes, e)
+	}
+	return report
+}
+
+// sponsored returns the number of bills sponsored by the council member the report is for
+func (r SimilarityReport) sponsored() int {
+	return r.row(r.Councilmember.ID).ExpectedSponsors
+}
+
+func (r SimilarityReport) row(ID int) SimilarityRow {
+	for _, row := range r.Rows {
+		if row.Councilmember.ID == ID {
+			return row
+		}
+	}
+	return SimilarityRow{}
+}
+
+func floatMatrix(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+	}
+	return m
+}
+
+// clusterAgreement runs average linkage hierarchical clustering with a distance of 100 - agreement.
+// Pairs that never voted on the same bill are ignored when averaging.
+//
+// It returns the leaf order of the dendrogram, the voting bloc for each member and the merges.
+// Blocs are the clusters just before the largest jump in merge distance.
+func clusterAgreement(agreement [][]float64, expected [][]int) ([]int, []int, []AgreementMerge) {
+	n := len(agreement)
+	type cluster struct {
+		ID      int
+		Members []int
+	}
+	var clusters []cluster
+	for i := 0; i < n; i++ {
+		clusters = append(clusters, cluster{ID: i, Members: []int{i}})
+	}
+	distance := func(a, b cluster) float64 {
+		var sum float64
+		var count int
+		for _, i := range a.Members {
+			for _, j := range b.Members {
+				if expected[i][j] == 0 {
+					continue
+				}
+				sum += 100 - agreement[i][j]
+				count++
+			}
+		}
+		if count == 0 {
+			return 100
+		}
+		return sum / float64(count)
+	}
+
+	merges := []AgreementMerge{}
+	partitions := [][][]int{} // the clusters before each merge
+	for len(clusters) > 1 {
+		var partition [][]int
+		for _, c := range clusters {
+			partition = append(partition, c.Members)
+		}
+		partitions = append(partitions, partition)
+
+		a, b, best := 0, 1, distance(clusters[0], clusters[1])
+		for i := range clusters {
+			for j := i + 1; j < len(clusters); j++ {
+				if d := distance(clusters[i], clusters[j]); d < best {
+					a, b, best = i, j, d
+				}
+			}
+		}
+		merged := cluster{ID: n + len(merges), Members: append(append([]int{}, clusters[a].Members...), clusters[b].Members...)}
+		merges = append(merges, AgreementMerge{Left: clusters[a].ID, Right: clusters[b].ID, Distance: best, Size: len(merged.Members)})
+		clusters[a] = merged
+		clusters = append(clusters[:b], clusters[b+1:]...)
+	}
+
+	var order []int
+	if len(clusters) == 1 {
+		order = clusters[0].Members
+	}
+	blocs := make([]int, n)
+
+	// find the largest jump in distance between successive merges
+	cut, gap := -1, 0.0
+	for m := 1; m < len(merges); m++ {
+		if d := merges[m].Distance - merges[m-1].Distance; d > gap {
+			cut, gap = m, d
+		}
+	}
+	if cut == -1 {
+		return order, blocs, merges
+	}
+	label := make(map[int]int, n) // member -> cluster in partition
+	for c, members := range partitions[cut] {
+		for _, i := range members {
+			label[i] = c
+		}
+	}
+	// number blocs in leaf order
+	bloc := make(map[int]int)
+	for _, i := range order {
+		if _, ok := bloc[label[i]]; !ok {
+			bloc[label[i]] = len(bloc)
+		}
+		blocs[i] = bloc[label[i]]
+	}
+	return order, blocs, merges
+}
+
+// BlocStart returns true when Councilmembers[i] is the first in a new voting bloc
+func (r AgreementReport) BlocStart(i int) bool {
+	return i > 0 && r.Councilmembers[i].Bloc != r.Councilmembers[i-1].Bloc
+}
+
+// Blocs returns the number of voting blocs
+func (r AgreementReport) Blocs() int {
+	var n int
+	for _, m := range r.Councilmembers {
+		n = max(n, m.Bloc+1)
+	}
+	return n
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jehiah/legislator/db"
+)
+
+func TestNewAgreementReport(t *testing.T) {
+	// a and c vote together, b and d vote together; a and b co-sponsor
+	names := []string{"a", "b", "c", "d"}
+	agree := map[string]int{"ac": 10, "bd": 9, "ab": 3, "ad": 2, "bc": 3, "cd": 2}
+	sponsored := map[string]int{"a": 4, "b": 2, "c": 0, "d": 1}
+	var reports []SimilarityReport
+	for i, x := range names {
+		report := SimilarityReport{Councilmember: db.PersonReference{ID: i + 1, Slug: x}}
+		for j, y := range names {
+			row := SimilarityRow{Councilmember: db.PersonReference{ID: j + 1, Slug: y}, ExpectedSponsors: sponsored[x], ExpectedVotes: 10, Votes: 10}
+			if x != y {
+				row.Votes = agree[x+y] + agree[y+x]
+			}
+			if x+y == "ab" || x+y == "ba" {
+				row.Sponsors = 2
+			}
+			if x == y {
+				row.Sponsors = sponsored[x]
+			}
+			report.Rows = append(report.Rows, row)
+		}
+		reports = append(reports, report)
+	}
+
+	r := NewAgreementReport(reports)
+	var order string
+	for _, m := range r.Councilmembers {
+		order += m.Councilmember.Slug
+	}
+	if order != "acbd" {
+		t.Errorf("got order %q", order)
+	}
+	if r.Blocs() != 2 || r.Councilmembers[1].Bloc != 0 || r.Councilmembers[2].Bloc != 1 || !r.BlocStart(2) {
+		t.Errorf("unexpected blocs %#v", r.Councilmembers)
+	}
+	if len(r.Merges) != 3 || r.Merges[0].Left != 0 || r.Merges[0].Right != 2 || r.Merges[0].Distance != 0 || r.Merges[2].Size != 4 {
+		t.Errorf("unexpected merges %#v", r.Merges)
+	}
+	// a (0) and b (2) share 2 of 4 bills sponsored by either; b and d 90% vote agreement
+	if r.Sponsors[0][2] != 50 || r.Sponsors[2][0] != 50 || r.Votes[2][3] != 90 || r.Votes[3][2] != 90 || r.ExpectedVotes[0][1] != 10 {
+		t.Errorf("unexpected matrix sponsors %v votes %v", r.Sponsors, r.Votes)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wantsCSV returns true for requests to a ".csv" path
+func wantsCSV(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, ".csv")
+}
+
+// csvReport is implemented by report data that can be exported as a CSV
+type csvReport interface {
+	CSV() [][]string // a header row followed by one record per row
+}
+
+// writeReport writes resp as CSV for a ".csv" request, and as JSON otherwise
+func (a *App) writeReport(w http.ResponseWriter, r *http.Request, cacheTTL time.Duration, resp ReportResponse) {
+	if !wantsCSV(r) {
+		a.writeReportJSON(w, cacheTTL, resp)
+		return
+	}
+	c, ok := resp.Data.(csvReport)
+	if !ok {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	a.writeCSV(w, cacheTTL, fmt.Sprintf("%s_%s.csv", resp.Report, resp.Session), c.CSV())
+}
+
+func (a *App) writeCSV(w http.ResponseWriter, cacheTTL time.Duration, filename string, records [][]string) {
+	if a.devMode {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	a.addExpireHeaders(w, cacheTTL)
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(records); err != nil {
+		log.Print(err)
+	}
+}
+
+func csvInt(i int) string         { return strconv.Itoa(i) }
+func csvBool(b bool) string       { return strconv.FormatBool(b) }
+func csvPercent(f float64) string { return strconv.FormatFloat(f, 'f', 1, 64) }
+func csvDate(t time.Time) string {
+	if t.IsZero() || t.Unix() == 0 {
+		return ""
+	}
+	return t.In(americaNewYork).Format("2006-01-02")
+}
+
+func (r MostSponsoredReport) CSV() [][]string {
+	o := [][]string{{"File", "Name", "Status", "Committee", "Intro Date", "Recent Action", "Recent Action Date", "Primary Sponsor",
+		"Sponsors", "Council Member Sponsors", "Committee Sponsors", "Committee Members", "Majority", "Super Majority", "Committee Majority"}}
+	for _, d := range r.Rows {
+		o = append(o, []string{d.File, d.Name, d.StatusName, d.BodyName, csvDate(d.IntroDate), d.RecentAction, csvDate(d.RecentDate), d.PrimarySponsor.FullName,
+			csvInt(d.Sponsors), csvInt(d.CouncilSponsors), csvInt(d.CommitteeSponsors), csvInt(d.CommitteeMembers),
+			csvBool(d.Majority), csvBool(d.SuperMajority), csvBool(d.CommitteeMajority)})
+	}
+	return o
+}
+
+func (r CouncilmembersReport) CSV() [][]string {
+	o := [][]string{{"Council Member", "Committee Role",
+		"Introduced", "Introduced with Hearing", "Introduced and Passed", "Introduced and Enacted", "Introduced and Vetoed",
+		"Sponsored", "Sponsored with Hearing", "Sponsored and Passed", "Sponsored and Enacted", "Sponsored and Vetoed"}}
+	for _, d := range r.Rows {
+		o = append(o, []string{d.Person.FullName, d.OfficeRecord.Title,
+			csvInt(d.IntroIntro), csvInt(d.IntroHearing), csvInt(d.IntroPassed), csvInt(d.IntroEnacted), csvInt(d.IntroVeto),
+			csvInt(d.SponsorIntro), csvInt(d.SponsorHearing), csvInt(d.SponsorPassed), csvInt(d.SponsorEnacted), csvInt(d.SponsorVeto)})
+	}
+	return o
+}
+
+func (r CommitteesReport) CSV() [][]string {
+	o := [][]string{{"Committee", "Bills", "Bills with Hearing", "Bills with Committee Vote", "Bills Passed Council", "Bills Enacted", "Hearings"}}
+	for _, d := range r.Rows {
+		o = append(o, []string{d.Committee, csvInt(d.BillTotal), csvInt(d.BillHearing), csvInt(d.BillCommitteeVote),
+			csvInt(d.BillPassedCouncil), csvInt(d.BillEnacted), csvInt(d.Hearings)})
+	}
+	return o
+}
+
+func (r AttendanceReport) CSV() [][]string {
+	o := [][]string{{"Council Member", "Party",
+		"Stated Meetings Present", "Stated Meetings", "Stated Meeting Attendance %",
+		"Committee Meetings Present", "Committee Meetings", "Committee Attendance %"}}
+	for _, d := range r.Rows {
+		o = append(o, []string{d.Councilmember.FullName, d.Party,
+			csvInt(d.CouncilRollCall), csvInt(d.ExpectedCouncilRollCall), csvPercent(d.CouncilPercent),
+			csvInt(d.CommitteeRollCall), csvInt(d.ExpectedCommitteeRollCall), csvPercent(d.CommitteePercent)})
+	}
+	return o
+}
+
+func (r ReintroductionsReport) CSV() [][]string {
+	o := [][]string{{"File", "Name", "Status", "Primary Sponsor",
+		"Reintroduced As", "Reintroduced Name", "Reintroduced Status", "Reintroduced Committee", "Reintroduced Primary Sponsor"}}
+	for _, d := range r.Rows {
+		row := []string{d.File, d.Name, d.StatusName, d.PrimarySponsor.FullName, "", "", "", "", ""}
+		if n := d.Reintroduction; n != nil {
+			copy(row[4:], []string{n.File, n.Name, n.StatusName, n.BodyName, n.PrimarySponsor.FullName})
+		}
+		o = append(o, row)
+	}
+	return o
+}
+
+func (r LifecycleReport) CSV() [][]string {
+	days := func(d *int) string {
+		if d == nil {
+			return ""
+		}
+		return csvInt(*d)
+	}
+	o := [][]string{{"File", "Name", "Status", "Committee", "Primary Sponsor", "Intro Date",
+		"Days to Hearing", "Days to Committee Vote", "Days to Council Vote", "Days to Enactment"}}
+	for _, d := range r.Rows {
+		o = append(o, []string{d.File, d.Name, d.StatusName, d.BodyName, d.PrimarySponsor.FullName, csvDate(d.IntroDate),
+			days(d.HearingDays), days(d.CommitteeVoteDays), days(d.CouncilVoteDays), days(d.EnactedDays)})
+	}
+	return o
+}
+
+func (r StalledReport) CSV() [][]string {
+	o := [][]string{{"File", "Name", "Status", "Committee", "Intro Date", "Primary Sponsor", "Last Hearing", "Days Stalled",
+		"Sponsors", "Council Member Sponsors", "Committee Sponsors", "Committee Members", "Majority", "Super Majority", "Committee Majority"}}
+	for _, d := range r.Rows {
+		var lastHearing string
+		if d.LastHearing != nil {
+			lastHearing = csvDate(*d.LastHearing)
+		}
+		o = append(o, []string{d.File, d.Name, d.StatusName, d.BodyName, csvDate(d.IntroDate), d.PrimarySponsor.FullName, lastHearing, csvInt(d.DaysStalled),
+			csvInt(d.Sponsors), csvInt(d.CouncilSponsors), csvInt(d.CommitteeSponsors), csvInt(d.CommitteeMembers),
+			csvBool(d.Majority), csvBool(d.SuperMajority), csvBool(d.CommitteeMajority)})
+	}
+	return o
+}
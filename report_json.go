@@ -0,0 +1,311 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jehiah/legislator/db"
+)
+
+// wantsJSON returns true for requests to a ".json" path or with an Accept header preferring application/json
+func wantsJSON(r *http.Request) bool {
+	if strings.HasSuffix(r.URL.Path, ".json") {
+		return true
+	}
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(v))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json":
+			return true
+		case "text/html", "*/*":
+			return false
+		}
+	}
+	return false
+}
+
+// varyAccept marks a response as negotiated on the Accept header so that
+// HTML and JSON responses are cached separately
+func varyAccept(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		h(w, r)
+	}
+}
+
+// ReportResponse is the JSON envelope for /reports/$report.json
+//
+// Data is one of the *Report types below. Fields are only ever added to
+// these types; existing fields are not renamed or removed.
+type ReportResponse struct {
+	Report   string            // i.e. "attendance"
+	Session  string            // i.e. "2024-2025"
+	LastSync time.Time         // when data was last synced from Legistar
+	Filters  map[string]string `json:",omitempty"` // i.e. committee, councilmember, sponsor
+	Data     any
+}
+
+// withIntroType adds a "type" filter for reports on resolutions or all legislation
+func withIntroType(filters map[string]string, introType string) map[string]string {
+	if introType == "introduction" {
+		return filters
+	}
+	if filters == nil {
+		filters = make(map[string]string)
+	}
+	filters["type"] = introType
+	return filters
+}
+
+func (a *App) writeReportJSON(w http.ResponseWriter, cacheTTL time.Duration, resp ReportResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	a.addExpireHeaders(w, cacheTTL)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(resp); err != nil {
+		log.Print(err)
+	}
+}
+
+// MostSponsoredReport is /reports/most_sponsored.json
+type MostSponsoredReport struct {
+	Rows []MostSponsoredRow // sorted by number of sponsors
+}
+
+type MostSponsoredRow struct {
+	File              string
+	Name              string
+	StatusName        string
+	BodyName          string // committee
+	IntroDate         time.Time
+	RecentAction      string
+	RecentDate        time.Time
+	PrimarySponsor    db.PersonReference
+	CommitteeMembers  int  // members of the committee
+	CommitteeSponsors int  // sponsors that are members of the committee
+	Sponsors          int  // all sponsors, including the Public Advocate and Borough Presidents
+	CouncilSponsors   int  // sponsors that are council members
+	Majority          bool // CouncilSponsors >= 26
+	SuperMajority     bool // CouncilSponsors >= 34
+	CommitteeMajority bool // a majority of committee members are sponsors
+}
+
+// StalledReport is /reports/stalled.json
+type StalledReport struct {
+	Days int          // minimum days without a hearing
+	Rows []StalledRow // sorted by DaysStalled
+}
+
+type StalledRow struct {
+	File              string
+	Name              string
+	StatusName        string
+	BodyName          string // committee
+	IntroDate         time.Time
+	PrimarySponsor    db.PersonReference
+	LastHearing       *time.Time `json:",omitempty"`
+	StalledSince      time.Time  // the last hearing, or IntroDate when there has not been a hearing
+	DaysStalled       int
+	CommitteeMembers  int
+	CommitteeSponsors int
+	Sponsors          int
+	CouncilSponsors   int
+	Majority          bool // CouncilSponsors >= 26
+	SuperMajority     bool // CouncilSponsors >= 34
+	CommitteeMajority bool
+}
+
+// SessionReport is /reports/session.json
+type SessionReport struct {
+	Rows []SessionReportRow // a running total for each Status ordered by Date
+}
+
+type SessionReportRow struct {
+	Date   string // RFC 3339
+	Count  int
+	Status string    // Introduced, Hearing Held, Passed Council, Enacted
+	Time   time.Time `json:"-"`
+	Last   bool      `json:"Last,omitempty"`
+}
+
+// SimilarityReport is /reports/similarity.json
+type SimilarityReport struct {
+	Councilmember db.PersonReference // the council member others are compared to
+	Rows          []SimilarityRow
+}
+
+type SimilarityRow struct {
+	Councilmember    db.PersonReference
+	Party            string  `json:",omitempty"`
+	ExpectedSponsors int     // bills sponsored by the selected Councilmember
+	Sponsors         int     // of those, the number also sponsored by this council member
+	SponsorPercent   float64 // 0-100
+	ExpectedVotes    int     // votes where both were present
+	Votes            int     // votes in agreement
+	VotePercent      float64 // 0-100
+}
+
+// AgreementReport is /reports/agreement.json
+//
+// Councilmembers are ordered by hierarchical clustering on vote agreement so
+// that voting blocs are adjacent. Sponsors[i][j] and Votes[i][j] compare
+// Councilmembers[i] with Councilmembers[j].
+type AgreementReport struct {
+	Councilmembers []AgreementMember
+	Sponsors       [][]float64 // co-sponsorship Jaccard index 0-100 (bills sponsored by both / bills sponsored by either)
+	Votes          [][]float64 // vote agreement 0-100
+	ExpectedVotes  [][]int     // votes where both were present
+	Merges         []AgreementMerge
+}
+
+type AgreementMember struct {
+	Councilmember db.PersonReference
+	Party         string `json:",omitempty"`
+	Bloc          int    // voting bloc; 0 is listed first
+}
+
+// AgreementMerge is a step of average linkage clustering. Clusters 0..n-1 are
+// Councilmembers (by index) and each merge creates cluster n+i.
+type AgreementMerge struct {
+	Left, Right int
+	Distance    float64 // 100 - average vote agreement
+	Size        int     // council members in the merged cluster
+}
+
+// LifecycleReport is /reports/lifecycle.json
+type LifecycleReport struct {
+	Stages []LifecycleStage // Hearing, Committee Vote, Council Vote, Enacted
+	Rows   []LifecycleRow
+}
+
+// LifecycleStage is the distribution of days from introduction to the first time a bill reached a stage
+type LifecycleStage struct {
+	Stage      string
+	Bills      int     // bills in the report
+	Reached    int     // bills that reached the stage
+	Percent    float64 // Reached / Bills 0-100
+	MinDays    int
+	P25Days    int
+	MedianDays int
+	P75Days    int
+	P90Days    int
+	MaxDays    int
+	MeanDays   float64
+	Histogram  []LifecycleBucket
+}
+
+type LifecycleBucket struct {
+	Label   string // i.e. "31-90 days"
+	MaxDays int    // 0 for the last (open ended) bucket
+	Count   int
+	Percent float64 // Count / Reached 0-100
+}
+
+type LifecycleRow struct {
+	File              string
+	Name              string
+	StatusName        string
+	BodyName          string // committee
+	PrimarySponsor    db.PersonReference
+	IntroDate         time.Time
+	HearingDays       *int `json:",omitempty"` // days from introduction to first hearing
+	CommitteeVoteDays *int `json:",omitempty"`
+	CouncilVoteDays   *int `json:",omitempty"`
+	EnactedDays       *int `json:",omitempty"` // signed by the Mayor, or enacted without a signature
+}
+
+// CouncilmembersReport is /reports/councilmembers.json
+type CouncilmembersReport struct {
+	Rows       []CouncilmemberReportRow
+	Committees []string // all committees with legislation in the session
+}
+
+type CouncilmemberReportRow struct {
+	Person       db.PersonReference
+	OfficeRecord db.OfficeRecord // committee membership when filtered by committee
+
+	// as primary sponsor
+	IntroIntro   int
+	IntroHearing int
+	IntroPassed  int
+	IntroEnacted int
+	IntroVeto    int
+
+	// as co-sponsor
+	SponsorIntro   int
+	SponsorHearing int
+	SponsorPassed  int
+	SponsorEnacted int
+	SponsorVeto    int
+}
+
+// CommitteesReport is /reports/committees.json
+type CommitteesReport struct {
+	Rows []CommitteeReportRow
+}
+
+type CommitteeReportRow struct {
+	Committee         string
+	BillTotal         int // bills referred to the committee
+	BillHearing       int
+	BillCommitteeVote int
+	BillPassedCouncil int
+	BillEnacted       int
+	Hearings          int // meetings with a roll call
+
+	HearingDates      map[string]bool `json:"-"`
+	OversightHearings int
+}
+
+// AttendanceReport is /reports/attendance.json
+type AttendanceReport struct {
+	CountedEvents     int // events with a roll call
+	FullCouncilEvents int // stated meetings with a roll call
+	Rows              []AttendanceRow
+}
+
+type AttendanceRow struct {
+	Councilmember             db.PersonReference
+	Party                     string `json:",omitempty"`
+	ExpectedCouncilRollCall   int
+	CouncilRollCall           int
+	CouncilPercent            float64 // 0-100
+	ExpectedCommitteeRollCall int
+	CommitteeRollCall         int
+	CommitteePercent          float64 // 0-100
+}
+
+// ReintroductionsReport is /reports/reintroductions.json
+type ReintroductionsReport struct {
+	PreviousSession string
+	FiledBills      int // bills from PreviousSession not enacted or withdrawn
+	Resubmitted     int
+	ResubmittedPct  float64
+	Sponsored       int     `json:",omitempty"` // when filtered by sponsor
+	Responsored     int     `json:",omitempty"`
+	ResponsoredPct  float64 `json:",omitempty"`
+	Rows            []ReintroductionRow
+}
+
+type ReintroductionRow struct {
+	File           string
+	Name           string
+	StatusName     string
+	PrimarySponsor db.PersonReference
+	Reintroduction *ReintroductionRef `json:",omitempty"`
+}
+
+type ReintroductionRef struct {
+	File           string
+	Name           string
+	StatusName     string
+	BodyName       string
+	PrimarySponsor db.PersonReference
+}
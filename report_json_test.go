@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWantsJSON(t *testing.T) {
+	type testCase struct {
+		path, accept string
+		expect       bool
+	}
+	for _, tc := range []testCase{
+		{"/reports/attendance", "", false},
+		{"/reports/attendance.json", "", true},
+		{"/reports/attendance", "application/json", true},
+		{"/reports/attendance", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"/reports/attendance", "application/json, text/plain, */*", true},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		if tc.accept != "" {
+			r.Header.Set("Accept", tc.accept)
+		}
+		if got := wantsJSON(r); got != tc.expect {
+			t.Errorf("wantsJSON(%q, %q) = %v expected %v", tc.path, tc.accept, got, tc.expect)
+		}
+	}
+}
+
+func TestReportCommitteesJSON(t *testing.T) {
+	year := CurrentSession.StartYear
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		fmt.Sprintf("build/%d.json", year): `[
+			{"File":"Int 0001-2026","BodyName":"Committee on Transportation and Infrastructure","History":[
+				{"Action":"Hearing Held by Committee","BodyName":"Committee on Transportation and Infrastructure"},
+				{"Action":"Approved by Council","BodyName":"City Council"}]},
+			{"File":"Int 0002-2026","BodyName":"Committee on Transportation and Infrastructure"}]`,
+	})
+
+	w := httptest.NewRecorder()
+	app.ReportCommittees(w, httptest.NewRequest("GET", "/reports/committees.json", nil))
+	if w.Code != 200 {
+		t.Fatalf("status %d %s", w.Code, w.Body)
+	}
+	var resp struct {
+		ReportResponse
+		Data CommitteesReport
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Report != "committees" || resp.Session != CurrentSession.String() || len(resp.Data.Rows) != 1 {
+		t.Fatalf("unexpected response %s", w.Body)
+	}
+	row := resp.Data.Rows[0]
+	if row.Committee != "Transportation and Infrastructure" || row.BillTotal != 2 || row.BillHearing != 1 || row.BillPassedCouncil != 1 {
+		t.Errorf("unexpected row %#v", row)
+	}
+}
+
+func TestReportCommitteesCSV(t *testing.T) {
+	year := CurrentSession.StartYear
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json":             `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		fmt.Sprintf("build/%d.json", year): `[{"File":"Int 0001-2026","BodyName":"Committee on Parks, Recreation"}]`,
+	})
+
+	w := httptest.NewRecorder()
+	app.ReportCommittees(w, httptest.NewRequest("GET", "/reports/committees.csv", nil))
+	if w.Code != 200 {
+		t.Fatalf("status %d %s", w.Code, w.Body)
+	}
+	expected := "Committee,Bills,Bills with Hearing,Bills with Committee Vote,Bills Passed Council,Bills Enacted,Hearings\n\"Parks, Recreation\",1,0,0,0,0,0\n"
+	if got := w.Body.String(); got != expected {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gosimple/slug"
+	"github.com/jehiah/legislator/db"
+)
+
+// lifecycleStages are the History actions that mark each stage of a bill
+var lifecycleStages = []struct {
+	Stage   string
+	Actions []string
+}{
+	{"Hearing", []string{"Hearing Held by Committee", "Hearing on P-C Item by Comm"}},
+	{"Committee Vote", []string{"Approved by Committee"}},
+	{"Council Vote", []string{"Approved by Council"}},
+	{"Enacted", []string{"Signed Into Law by Mayor", "City Charter Rule Adopted",
+		"Overridden by Council"}}, // possible after "Vetoed by Mayor" (See Int 1208-2013)
+}
+
+// lifecycleBuckets are the upper bound (in days) of each histogram bucket
+var lifecycleBuckets = []int{30, 90, 180, 365, 730}
+
+// ReportLifecycle shows how long bills take to reach a hearing, committee vote, council vote
+// and enactment at /reports/lifecycle
+//
+// Filter with committee=$committee_slug and sponsor=$primary_sponsor_slug
+func (a *App) ReportLifecycle(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	template := "report_lifecycle.html"
+
+	t := newTemplate(a.templateFS, template)
+
+	type Page struct {
+		Page              string
+		SubPage           string
+		LastSync          LastSync
+		Session           Session
+		Sessions          []Session
+		IntroType         string
+		Committees        []string
+		Sponsors          []db.PersonReference
+		SelectedCommittee string
+		SelectedSponsor   string
+		Data              LifecycleReport
+	}
+	body := Page{
+		Page:              "reports",
+		SubPage:           "lifecycle",
+		Session:           CurrentSession,
+		Sessions:          Sessions,
+		SelectedCommittee: r.Form.Get("committee"),
+		SelectedSponsor:   r.Form.Get("sponsor"),
+	}
+	for _, s := range Sessions {
+		if s.String() == r.Form.Get("session") {
+			body.Session = s
+		}
+	}
+	var introTypes []string
+	var err error
+	body.IntroType, introTypes, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
+
+	legislation, err := a.getSessionLegislation(r.Context(), body.Session, introTypes, "")
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	committees := make(map[string]bool)
+	sponsors := make(map[string]db.PersonReference)
+	var filtered LegislationList
+	for _, l := range legislation {
+		if l.StatusName == "Withdrawn" {
+			continue
+		}
+		committees[TrimCommittee(l.BodyName)] = true
+		sponsor := l.PrimarySponsor()
+		if sponsor.Slug != "" {
+			sponsors[sponsor.Slug] = sponsor
+		}
+		if body.SelectedCommittee != "" && slug.Make(TrimCommittee(l.BodyName)) != body.SelectedCommittee {
+			continue
+		}
+		if body.SelectedSponsor != "" && sponsor.Slug != body.SelectedSponsor {
+			continue
+		}
+		filtered = append(filtered, l)
+	}
+	for c := range committees {
+		body.Committees = append(body.Committees, c)
+	}
+	sort.Strings(body.Committees)
+	for _, s := range sponsors {
+		body.Sponsors = append(body.Sponsors, s)
+	}
+	sort.Slice(body.Sponsors, func(i, j int) bool { return body.Sponsors[i].FullName < body.Sponsors[j].FullName })
+
+	body.Data = NewLifecycleReport(filtered)
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	cacheTTL := time.Minute * 15
+	if !body.Session.IsCurrent() {
+		cacheTTL = time.Hour * 24
+	}
+	if wantsJSON(r) || wantsCSV(r) {
+		filters := make(map[string]string)
+		if body.SelectedCommittee != "" {
+			filters["committee"] = body.SelectedCommittee
+		}
+		if body.SelectedSponsor != "" {
+			filters["sponsor"] = body.SelectedSponsor
+		}
+		if len(filters) == 0 {
+			filters = nil
+		}
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "lifecycle",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  withIntroType(filters, body.IntroType),
+			Data:     body.Data,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// NewLifecycleReport measures the days from introduction to the first action of each stage
+func NewLifecycleReport(legislation LegislationList) LifecycleReport {
+	report := LifecycleReport{Stages: []LifecycleStage{}, Rows: []LifecycleRow{}}
+	days := make([][]int, len(lifecycleStages))
+	for _, l := range legislation {
+		row := LifecycleRow{
+			File:           l.File,
+			Name:           l.Name,
+			StatusName:     l.StatusName,
+			BodyName:       l.BodyName,
+			PrimarySponsor: l.PrimarySponsor(),
+			IntroDate:      l.IntroDate,
+		}
+		fields := []**int{&row.HearingDays, &row.CommitteeVoteDays, &row.CouncilVoteDays, &row.EnactedDays}
+		for i, stage := range lifecycleStages {
+			date, ok := l.firstAction(stage.Actions...)
+			if !ok {
+				continue
+			}
+			// a "Hearing on P-C Item by Comm" (pre-considered) can be before introduction
+			d := max(0, daysBetween(l.IntroDate, date))
+			*fields[i] = &d
+			days[i] = append(days[i], d)
+		}
+		report.Rows = append(report.Rows, row)
+	}
+
+	for i, stage := range lifecycleStages {
+		report.Stages = append(report.Stages, newLifecycleStage(stage.Stage, len(legislation), days[i]))
+	}
+	return report
+}
+
+func newLifecycleStage(stage string, bills int, days []int) LifecycleStage {
+	s := LifecycleStage{
+		Stage:     stage,
+		Bills:     bills,
+		Reached:   len(days),
+		Histogram: []LifecycleBucket{},
+	}
+	if bills > 0 {
+		s.Percent = (float64(s.Reached) / float64(bills)) * 100
+	}
+	low := 0
+	for i := 0; i <= len(lifecycleBuckets); i++ {
+		var b LifecycleBucket
+		if i < len(lifecycleBuckets) {
+			b.MaxDays = lifecycleBuckets[i]
+			b.Label = fmt.Sprintf("%d-%d days", low, b.MaxDays)
+		} else {
+			b.Label = fmt.Sprintf("%d+ days", low)
+		}
+		for _, d := range days {
+			if d >= low && (b.MaxDays == 0 || d <= b.MaxDays) {
+				b.Count++
+			}
+		}
+		if s.Reached > 0 {
+			b.Percent = (float64(b.Count) / float64(s.Reached)) * 100
+		}
+		s.Histogram = append(s.Histogram, b)
+		low = b.MaxDays + 1
+	}
+	if len(days) == 0 {
+		return s
+	}
+
+	sort.Ints(days)
+	percentile := func(p int) int {
+		// nearest rank
+		i := (p*len(days)+99)/100 - 1
+		return days[max(i, 0)]
+	}
+	var total int
+	for _, d := range days {
+		total += d
+	}
+	s.MinDays = days[0]
+	s.P25Days = percentile(25)
+	s.MedianDays = percentile(50)
+	s.P75Days = percentile(75)
+	s.P90Days = percentile(90)
+	s.MaxDays = days[len(days)-1]
+	s.MeanDays = float64(total) / float64(len(days))
+	return s
+}
+
+func (r LifecycleRow) IntroLink() string {
+	id, _ := ParseFile(r.File)
+	return "/" + string(id)
+}
+
+// firstAction returns the date of the first History entry with one of actions
+func (ll Legislation) firstAction(actions ...string) (time.Time, bool) {
+	for _, h := range ll.History {
+		for _, a := range actions {
+			if h.Action == a {
+				return h.Date, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// daysBetween returns the number of calendar days (in New York) from a to b
+func daysBetween(a, b time.Time) int {
+	a, b = a.In(americaNewYork), b.In(americaNewYork)
+	start := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
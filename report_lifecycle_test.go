@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReportLifecycle(t *testing.T) {
+	year := CurrentSession.StartYear
+	history := func(actions ...string) string {
+		var o []string
+		for i := 0; i < len(actions); i += 2 {
+			o = append(o, fmt.Sprintf(`{"Action":%q,"Date":"%d-%sT15:00:00Z"}`, actions[i], year, actions[i+1]))
+		}
+		return "[" + strings.Join(o, ",") + "]"
+	}
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		fmt.Sprintf("build/%d.json", year): fmt.Sprintf(`[
+			{"File":"Int 0001-%[1]d","Name":"Bike Lanes","BodyName":"Committee on Transportation","IntroDate":"%[1]d-02-01T15:00:00Z","Sponsors":[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe"}],"History":%[2]s},
+			{"File":"Int 0002-%[1]d","Name":"Parks","BodyName":"Committee on Parks","IntroDate":"%[1]d-02-01T15:00:00Z","Sponsors":[{"ID":2,"Slug":"john-roe","FullName":"John Roe"}],"History":%[3]s},
+			{"File":"Int 0003-%[1]d","Name":"Buses","BodyName":"Committee on Transportation","IntroDate":"%[1]d-02-01T15:00:00Z","Sponsors":[{"ID":2,"Slug":"john-roe","FullName":"John Roe"}],"History":[]},
+			{"File":"Int 0004-%[1]d","Name":"Withdrawn","StatusName":"Withdrawn","BodyName":"Committee on Parks","IntroDate":"%[1]d-02-01T15:00:00Z","History":[]}]`, year,
+			history("Hearing Held by Committee", "03-03", "Hearing Held by Committee", "04-01", "Approved by Committee", "05-03", "Approved by Council", "05-04", "Signed Into Law by Mayor", "06-01"),
+			history("Hearing Held by Committee", "05-02")),
+	})
+
+	r := NewLifecycleReport(nil)
+	if len(r.Stages) != 4 || r.Stages[0].Reached != 0 || len(r.Stages[0].Histogram) != 6 {
+		t.Errorf("unexpected empty report %#v", r)
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ReportLifecycle(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 {
+			t.Fatalf("GET %s status %d %s", path, w.Code, w.Body)
+		}
+		return w
+	}
+
+	w := get("/reports/lifecycle.csv")
+	records := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(records) != 4 || records[1] != fmt.Sprintf("Int 0001-%d,Bike Lanes,,Committee on Transportation,Jane Doe,%d-02-01,30,91,92,120", year, year) {
+		t.Errorf("unexpected CSV %q", records)
+	}
+
+	w = get("/reports/lifecycle.json?committee=transportation")
+	if !strings.Contains(w.Body.String(), `"committee": "transportation"`) || strings.Contains(w.Body.String(), "Parks") {
+		t.Errorf("unexpected committee filter %s", w.Body)
+	}
+
+	w = get("/reports/lifecycle?sponsor=john-roe")
+	body := w.Body.String()
+	if !strings.Contains(body, "Buses") || strings.Contains(body, "Bike Lanes") {
+		t.Errorf("unexpected sponsor filter %s", body)
+	}
+
+	// hearings on pre-considered items before introduction count as day 0
+	var preconsidered LegislationList
+	if err := json.Unmarshal([]byte(fmt.Sprintf(`[{"File":"Int 0005-%[1]d","IntroDate":"%[1]d-02-01T15:00:00Z","History":%[2]s}]`,
+		year, history("Hearing on P-C Item by Comm", "01-20"))), &preconsidered); err != nil {
+		t.Fatal(err)
+	}
+	r = NewLifecycleReport(preconsidered)
+	if d := r.Rows[0].HearingDays; d == nil || *d != 0 {
+		t.Errorf("unexpected hearing days %v", d)
+	}
+	if s := r.Stages[0]; s.Reached != 1 || s.MinDays != 0 || s.P25Days != 0 || s.Histogram[0].Count != 1 {
+		t.Errorf("unexpected stage %#v", s)
+	}
+
+	stage := newLifecycleStage("Hearing", 4, []int{10, 40, 20, 100})
+	if stage.Percent != 100 || stage.MedianDays != 20 || stage.P25Days != 10 || stage.P90Days != 100 || stage.MeanDays != 42.5 ||
+		stage.Histogram[0].Count != 2 || stage.Histogram[1].Count != 1 || stage.Histogram[2].Count != 1 {
+		t.Errorf("unexpected stage %#v", stage)
+	}
+}
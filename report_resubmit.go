@@ -4,12 +4,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"sort"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/jehiah/legislator/db"
 )
 
@@ -125,7 +123,7 @@ func (a *App) ReportReintroductions(w http.ResponseWriter, r *http.Request) {
 		var resubmitFile ResubmitFile
 		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/resubmit_%d.json", year), &resubmitFile)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
 			log.Print(err)
@@ -144,7 +142,7 @@ func (a *App) ReportReintroductions(w http.ResponseWriter, r *http.Request) {
 		var l []Legislation
 		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
 			log.Print(err)
@@ -166,7 +164,7 @@ func (a *App) ReportReintroductions(w http.ResponseWriter, r *http.Request) {
 		var l []Legislation
 		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
 			log.Print(err)
@@ -230,8 +228,51 @@ func (a *App) ReportReintroductions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("content-type", "text/html")
 	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		report := ReintroductionsReport{
+			PreviousSession: body.PreviousSession.String(),
+			FiledBills:      body.FiledBills,
+			Resubmitted:     body.Resubmitted,
+			ResubmittedPct:  body.ResubmittPct,
+			Sponsored:       body.Sponsored,
+			Responsored:     body.Responsored,
+			ResponsoredPct:  body.ResponsoredPct,
+			Rows:            []ReintroductionRow{},
+		}
+		for _, d := range body.Data {
+			row := ReintroductionRow{
+				File:           d.File,
+				Name:           d.Name,
+				StatusName:     d.StatusName,
+				PrimarySponsor: d.PrimarySponsor(),
+			}
+			if n := d.NewLegislation; n != nil {
+				row.Reintroduction = &ReintroductionRef{
+					File:           n.File,
+					Name:           n.Name,
+					StatusName:     n.StatusName,
+					BodyName:       n.BodyName,
+					PrimarySponsor: n.PrimarySponsor(),
+				}
+			}
+			report.Rows = append(report.Rows, row)
+		}
+		var filters map[string]string
+		if body.Person.Slug != "" {
+			filters = map[string]string{"sponsor": body.Person.Slug}
+		}
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "reintroductions",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  filters,
+			Data:     report,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
 	a.addExpireHeaders(w, cacheTTL)
 	err = t.ExecuteTemplate(w, template, body)
 	if err != nil {
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Report snapshots are precomputed by `intro.nyc build-reports` after each sync
+// and stored as build/reports/$report_$session.json. Handlers render from a
+// snapshot when one exists and compute the report live when it does not.
+//
+// Only the aggregate reports are snapshotted; most_sponsored and
+// reintroductions list individual bills and are cheap to compute from the
+// per-year files. Snapshots only cover introductions; reports with
+// type=resolution or type=all are always computed.
+
+func reportSnapshotFile(report string, s Session) string {
+	return fmt.Sprintf("build/reports/%s_%s.json", report, s)
+}
+
+// getReportSnapshot decodes the snapshot for report into v. It returns false if there is no snapshot.
+func (a *App) getReportSnapshot(ctx context.Context, report string, s Session, v interface{}) (bool, error) {
+	err := a.getJSONFile(ctx, reportSnapshotFile(report, s), v)
+	if err != nil {
+		if isNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *App) putReportSnapshot(ctx context.Context, report string, s Session, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	filename := reportSnapshotFile(report, s)
+	log.Printf("writing %s", filename)
+	return a.store.Put(ctx, filename, "application/json", bytes.NewReader(body))
+}
+
+// buildReports computes each snapshotted report for sessions and writes it to the store
+func (a *App) buildReports(ctx context.Context, sessions []Session) error {
+	for _, s := range sessions {
+		session, err := a.computeSessionReport(ctx, s, []string{"introduction"})
+		if err != nil {
+			return err
+		}
+		similarity, err := a.computeSimilarityReports(ctx, s, []string{"introduction"})
+		if err != nil {
+			return err
+		}
+		councilmembers, err := a.computeCouncilmembersReport(ctx, s, "")
+		if err != nil {
+			return err
+		}
+		committees, err := a.computeCommitteesReport(ctx, s)
+		if err != nil {
+			return err
+		}
+		attendance, err := a.computeAttendanceReport(ctx, s)
+		if err != nil {
+			return err
+		}
+		for report, v := range map[string]interface{}{
+			"session":        session,
+			"similarity":     similarity,
+			"agreement":      NewAgreementReport(similarity),
+			"councilmembers": councilmembers,
+			"committees":     committees,
+			"attendance":     attendance,
+		} {
+			if err := a.putReportSnapshot(ctx, report, s, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseSessions returns the sessions named in args ("2024-2025" or "all"); the current session when empty
+func parseSessions(args []string) ([]Session, error) {
+	if len(args) == 0 {
+		return []Session{CurrentSession}, nil
+	}
+	var o []Session
+	for _, arg := range args {
+		if arg == "all" {
+			return Sessions, nil
+		}
+		s, ok := ParseSession(arg)
+		if !ok {
+			return nil, fmt.Errorf("unknown session %q", arg)
+		}
+		o = append(o, s)
+	}
+	return o, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildReports(t *testing.T) {
+	year := CurrentSession.StartYear
+	start := fmt.Sprintf("%d-01-01T00:00:00Z", year)
+	end := fmt.Sprintf("%d-12-31T00:00:00Z", CurrentSession.EndYear)
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json": `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		"build/people_all.json": `[
+			{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","OfficeRecords":[{"BodyName":"City Council","MemberType":"Council Member","Start":"` + start + `","End":"` + end + `"}]},
+			{"ID":2,"Slug":"john-roe","FullName":"John Roe","OfficeRecords":[{"BodyName":"City Council","MemberType":"Council Member","Start":"` + start + `","End":"` + end + `"}]}]`,
+		fmt.Sprintf("build/%d.json", year): `[{"File":"Int 0001-2026","BodyName":"Committee on Parks","Sponsors":[{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe"}]}]`,
+		fmt.Sprintf("build/%d_votes.json", year): `[{"File":"Int 0001-2026","Sponsors":[{"ID":1},{"ID":2}],"History":[
+			{"Votes":[{"ID":1,"VoteID":15},{"ID":2,"VoteID":12}]}]}]`,
+	})
+
+	if err := app.buildReports(context.Background(), []Session{CurrentSession}); err != nil {
+		t.Fatal(err)
+	}
+	for _, report := range []string{"session", "similarity", "agreement", "councilmembers", "committees", "attendance"} {
+		if _, err := app.store.Stat(context.Background(), reportSnapshotFile(report, CurrentSession)); err != nil {
+			t.Errorf("missing snapshot for %s %s", report, err)
+		}
+	}
+
+	var similarity []SimilarityReport
+	if ok, err := app.getReportSnapshot(context.Background(), "similarity", CurrentSession, &similarity); !ok || err != nil {
+		t.Fatalf("getReportSnapshot %v %v", ok, err)
+	}
+	if len(similarity) != 2 || similarity[0].Rows[1].Sponsors != 1 || similarity[0].Rows[1].ExpectedVotes != 1 || similarity[0].Rows[1].Votes != 0 {
+		t.Errorf("unexpected similarity %#v", similarity)
+	}
+
+	// handlers render from the snapshot even when the underlying data changes
+	app.store.Put(context.Background(), fmt.Sprintf("build/%d.json", year), "", strings.NewReader(`[]`))
+	app.fileCache = make(map[string]CachedFile)
+	report, err := app.getCommitteesReport(context.Background(), CurrentSession)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rows) != 1 || report.Rows[0].Committee != "Parks" {
+		t.Errorf("expected committees report from snapshot got %#v", report)
+	}
+
+	for _, path := range []string{"/reports/similarity?councilmember=jane-doe", "/reports/attendance", "/reports/agreement"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		switch {
+		case strings.Contains(path, "similarity"):
+			app.ReportSimilarity(w, r)
+		case strings.Contains(path, "agreement"):
+			app.ReportAgreement(w, r)
+		default:
+			app.ReportAttendance(w, r)
+		}
+		if w.Code != 200 || !strings.Contains(w.Body.String(), "John Roe") {
+			t.Errorf("%s status %d %s", path, w.Code, w.Body)
+		}
+	}
+}
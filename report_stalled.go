@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stalledDefaultDays is the default for days=
+const stalledDefaultDays = 90
+
+// stalledDays are the choices for days= shown on /reports/stalled
+var stalledDays = []int{30, 60, 90, 180, 365}
+
+// ReportStalled lists bills in committee with a majority or supermajority of sponsors, or a majority
+// of the committee as sponsors, that have had no hearing in days= (default 90) at /reports/stalled
+//
+// Also available as /reports/stalled.atom so advocates can follow bills as they stall
+func (a *App) ReportStalled(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	template := "report_stalled.html"
+
+	t := newTemplate(a.templateFS, template)
+
+	type Page struct {
+		Page        string
+		SubPage     string
+		LastSync    LastSync
+		IntroType   string
+		Days        int
+		DefaultDays int
+		DayChoices  []int
+		Data        StalledReport
+	}
+	body := Page{
+		Page:        "reports",
+		SubPage:     "stalled",
+		Days:        stalledDefaultDays,
+		DefaultDays: stalledDefaultDays,
+		DayChoices:  stalledDays,
+	}
+	if d := r.Form.Get("days"); d != "" {
+		days, err := strconv.Atoi(d)
+		if err != nil || days < 0 {
+			http.Error(w, "invalid days", 400)
+			return
+		}
+		body.Days = days
+	}
+	var introTypes []string
+	var err error
+	body.IntroType, introTypes, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
+
+	committeeMembers, err := a.getCommitteeMembers(r.Context())
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	legislation, err := a.getSessionLegislation(r.Context(), CurrentSession, introTypes, "")
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	body.Data = NewStalledReport(legislation, committeeMembers, body.Days, time.Now())
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	cacheTTL := time.Minute * 15
+	var filters map[string]string
+	if r.Form.Get("days") != "" {
+		filters = map[string]string{"days": strconv.Itoa(body.Days)}
+	}
+	filters = withIntroType(filters, body.IntroType)
+	switch {
+	case strings.HasSuffix(r.URL.Path, ".atom"):
+		a.addExpireHeaders(w, cacheTTL)
+		a.writeAtom(w, body.Data.Feed(filters))
+		return
+	case wantsJSON(r) || wantsCSV(r):
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "stalled",
+			Session:  CurrentSession.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  filters,
+			Data:     body.Data,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// NewStalledReport finds bills still in committee with majority, supermajority or committee
+// majority sponsorship and no hearing for at least days as of now
+func NewStalledReport(legislation LegislationList, committeeMembers map[string]map[int]bool, days int, now time.Time) StalledReport {
+	report := StalledReport{Days: days, Rows: []StalledRow{}}
+	for _, l := range legislation {
+		switch l.StatusName {
+		case "Introduced", "Committee", "Laid Over in Committee":
+		default:
+			continue
+		}
+		c := NewCommitteeSponsorship(l, committeeMembers[l.BodyName])
+		if !c.Majority() && !c.SuperMajority() && !c.CommitteeMajority() {
+			continue
+		}
+		row := StalledRow{
+			File:              l.File,
+			Name:              l.Name,
+			StatusName:        l.StatusName,
+			BodyName:          l.BodyName,
+			IntroDate:         l.IntroDate,
+			PrimarySponsor:    l.PrimarySponsor(),
+			StalledSince:      l.IntroDate,
+			CommitteeMembers:  c.CommitteeMembers,
+			CommitteeSponsors: c.CommitteeSponsors,
+			Sponsors:          c.Sponsors,
+			CouncilSponsors:   c.CouncilmemberSponsors,
+			Majority:          c.Majority(),
+			SuperMajority:     c.SuperMajority(),
+			CommitteeMajority: c.CommitteeMajority(),
+		}
+		if hearings := l.Hearings(); len(hearings) > 0 {
+			d := hearings[len(hearings)-1].Date
+			row.LastHearing = &d
+			row.StalledSince = d
+		}
+		row.DaysStalled = daysBetween(row.StalledSince, now)
+		if row.DaysStalled < days {
+			continue
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	sort.SliceStable(report.Rows, func(i, j int) bool {
+		if report.Rows[i].DaysStalled == report.Rows[j].DaysStalled {
+			return report.Rows[i].CouncilSponsors > report.Rows[j].CouncilSponsors
+		}
+		return report.Rows[i].DaysStalled > report.Rows[j].DaysStalled
+	})
+	return report
+}
+
+func (r StalledRow) IntroLink() string {
+	id, _ := ParseFile(r.File)
+	return "/" + string(id)
+}
+
+// Sponsorship describes why the bill is included i.e. "a veto-proof supermajority (36 council members)"
+func (r StalledRow) Sponsorship() string {
+	var o []string
+	switch {
+	case r.SuperMajority:
+		o = append(o, fmt.Sprintf("a veto-proof supermajority (%d council members)", r.CouncilSponsors))
+	case r.Majority:
+		o = append(o, fmt.Sprintf("a majority (%d council members)", r.CouncilSponsors))
+	}
+	if r.CommitteeMajority {
+		o = append(o, fmt.Sprintf("%d of %d committee members", r.CommitteeSponsors, r.CommitteeMembers))
+	}
+	return strings.Join(o, " and ")
+}
+
+// Feed returns an entry for each bill dated when it reached Days without a hearing
+func (r StalledReport) Feed(filters map[string]string) AtomFeed {
+	var qs string
+	if len(filters) > 0 {
+		v := url.Values{}
+		for k, f := range filters {
+			v.Set(k, f)
+		}
+		qs = "?" + v.Encode()
+	}
+	feed := AtomFeed{
+		ID:       atomID(CurrentSession.StartDate(), fmt.Sprintf("reports/stalled/%d", r.Days)),
+		Title:    "Stalled Legislation",
+		Subtitle: fmt.Sprintf("NYC Council legislation with majority or committee majority sponsorship and no hearing in %d days", r.Days),
+		Links: []AtomLink{
+			{Href: "https://intro.nyc/reports/stalled.atom" + qs, Rel: "self", Type: "application/atom+xml"},
+			{Href: "https://intro.nyc/reports/stalled" + qs, Rel: "alternate", Type: "text/html"},
+		},
+	}
+	for _, row := range r.Rows {
+		stalled := row.StalledSince.AddDate(0, 0, r.Days)
+		content := &strings.Builder{}
+		fmt.Fprintf(content, "%s\n\nSponsored by %s", row.Name, row.Sponsorship())
+		if row.PrimarySponsor.FullName != "" {
+			fmt.Fprintf(content, "; introduced by %s", row.PrimarySponsor.FullName)
+		}
+		fmt.Fprintf(content, " on %s.\n\n", row.IntroDate.In(americaNewYork).Format("January 2, 2006"))
+		if row.LastHearing != nil {
+			fmt.Fprintf(content, "Last hearing %s by the %s (%d days ago)\n", row.LastHearing.In(americaNewYork).Format("January 2, 2006"), row.BodyName, row.DaysStalled)
+		} else {
+			fmt.Fprintf(content, "No hearing by the %s in %d days\n", row.BodyName, row.DaysStalled)
+		}
+		feed.Entries = append(feed.Entries, AtomEntry{
+			ID:         atomID(stalled, fmt.Sprintf("%s/stalled/%d", row.IntroLink()[1:], r.Days)),
+			Title:      fmt.Sprintf("%s has had no hearing in %d days: %s", row.File, r.Days, row.Name),
+			Updated:    stalled,
+			Published:  &stalled,
+			Links:      []AtomLink{{Href: "https://intro.nyc" + row.IntroLink() + "+", Rel: "alternate", Type: "text/html"}},
+			Categories: []AtomCategory{{Term: TrimCommittee(row.BodyName)}},
+			Content:    &AtomText{Type: "text", Body: content.String()},
+		})
+	}
+	// newest first
+	sort.SliceStable(feed.Entries, func(i, j int) bool { return feed.Entries[i].Updated.After(feed.Entries[j].Updated) })
+	return feed
+}
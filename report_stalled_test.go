@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jehiah/legislator/db"
+)
+
+func TestNewStalledReport(t *testing.T) {
+	sponsors := func(n int) []db.PersonReference {
+		var o []db.PersonReference
+		for i := 1; i <= n; i++ {
+			o = append(o, db.PersonReference{ID: i, FullName: fmt.Sprintf("Member %d", i)})
+		}
+		return o
+	}
+	date := func(s string) time.Time {
+		d, _ := time.Parse(time.RFC3339, s+"T15:00:00Z")
+		return d
+	}
+	legislation := LegislationList{
+		{db.Legislation{File: "Int 0001-2024", Name: "Majority", StatusName: "Committee", BodyName: "Committee on Transportation", IntroDate: date("2024-02-01"), Sponsors: sponsors(30)}},
+		{db.Legislation{File: "Int 0002-2024", Name: "Supermajority", StatusName: "Committee", BodyName: "Committee on Transportation", IntroDate: date("2024-02-01"), Sponsors: sponsors(40),
+			History: []db.History{{Action: "Hearing Held by Committee", Date: date("2024-03-01")}}}},
+		{db.Legislation{File: "Int 0003-2024", Name: "Committee Majority", StatusName: "Laid Over in Committee", BodyName: "Committee on Parks", IntroDate: date("2024-02-01"), Sponsors: sponsors(3),
+			History: []db.History{{Action: "Hearing Held by Committee", Date: date("2024-05-01")}}}},
+		{db.Legislation{File: "Int 0004-2024", Name: "Few Sponsors", StatusName: "Committee", BodyName: "Committee on Parks", IntroDate: date("2024-02-01"), Sponsors: sponsors(2)}},
+		{db.Legislation{File: "Int 0005-2024", Name: "Enacted", StatusName: "Enacted", BodyName: "Committee on Transportation", IntroDate: date("2024-02-01"), Sponsors: sponsors(40)}},
+		{db.Legislation{File: "Int 0006-2024", Name: "Recent Hearing", StatusName: "Committee", BodyName: "Committee on Transportation", IntroDate: date("2024-02-01"), Sponsors: sponsors(40),
+			History: []db.History{{Action: "Hearing Held by Committee", Date: date("2024-06-15")}}}},
+	}
+	committeeMembers := map[string]map[int]bool{
+		"Committee on Parks": {1: true, 2: true, 3: true, 50: true, 51: true},
+	}
+
+	r := NewStalledReport(legislation, committeeMembers, 30, date("2024-07-01"))
+	var files []string
+	for _, row := range r.Rows {
+		files = append(files, row.File)
+	}
+	if got := strings.Join(files, ","); got != "Int 0001-2024,Int 0002-2024,Int 0003-2024" {
+		t.Fatalf("got %s", got)
+	}
+	if r.Rows[0].DaysStalled != 151 || r.Rows[0].LastHearing != nil || r.Rows[0].Sponsorship() != "a majority (30 council members)" {
+		t.Errorf("unexpected row %#v", r.Rows[0])
+	}
+	if r.Rows[1].DaysStalled != 122 || !r.Rows[1].LastHearing.Equal(date("2024-03-01")) || r.Rows[1].Sponsorship() != "a veto-proof supermajority (40 council members)" {
+		t.Errorf("unexpected row %#v", r.Rows[1])
+	}
+	if r.Rows[2].Sponsorship() != "3 of 5 committee members" {
+		t.Errorf("unexpected sponsorship %q", r.Rows[2].Sponsorship())
+	}
+
+	feed := r.Feed(map[string]string{"days": "30"})
+	if len(feed.Entries) != 3 || feed.Links[0].Href != "https://intro.nyc/reports/stalled.atom?days=30" {
+		t.Fatalf("unexpected feed %#v", feed)
+	}
+	// newest first; Int 0003 reached 30 days without a hearing on May 31
+	if feed.Entries[0].Links[0].Href != "https://intro.nyc/0003-2024+" || !feed.Entries[0].Updated.Equal(date("2024-05-31")) {
+		t.Errorf("unexpected entry %#v", feed.Entries[0])
+	}
+}
+
+func TestReportStalled(t *testing.T) {
+	year := CurrentSession.StartYear
+	var sponsors []string
+	for i := 1; i <= 34; i++ {
+		sponsors = append(sponsors, fmt.Sprintf(`{"ID":%d,"Slug":"member-%d","FullName":"Member %d"}`, i, i, i))
+	}
+	app := newTestApp(t, map[string]string{
+		"build/last_sync.json":  `{"LastRun":"2026-02-01T00:00:00Z"}`,
+		"build/people_all.json": `[]`,
+		fmt.Sprintf("build/%d.json", year): fmt.Sprintf(`[
+			{"File":"Int 0001-%[1]d","Name":"Bike Lanes","StatusName":"Committee","BodyName":"Committee on Transportation","IntroDate":"%[1]d-01-08T15:00:00Z","Sponsors":[%[2]s]},
+			{"File":"Int 0002-%[1]d","Name":"Parks","StatusName":"Committee","BodyName":"Committee on Parks","IntroDate":"%[1]d-01-08T15:00:00Z","Sponsors":[{"ID":1,"Slug":"member-1","FullName":"Member 1"}]}]`,
+			year, strings.Join(sponsors, ",")),
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.ReportStalled(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != 200 {
+			t.Fatalf("GET %s status %d %s", path, w.Code, w.Body)
+		}
+		return w
+	}
+
+	w := get("/reports/stalled.json?days=0")
+	if body := w.Body.String(); !strings.Contains(body, `"days": "0"`) || !strings.Contains(body, "Bike Lanes") || strings.Contains(body, "Parks") {
+		t.Errorf("unexpected JSON %s", body)
+	}
+	w = get("/reports/stalled.csv?days=0")
+	if records := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(records) != 2 {
+		t.Errorf("unexpected CSV %q", records)
+	}
+	w = get("/reports/stalled.atom?days=0")
+	if body := w.Body.String(); !strings.Contains(body, "Int 0001-") || !strings.Contains(body, "supermajority") {
+		t.Errorf("unexpected feed %s", body)
+	}
+	w = get("/reports/stalled?days=0")
+	if !strings.Contains(w.Body.String(), "Bike Lanes") {
+		t.Errorf("unexpected page %s", w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	app.ReportStalled(w, httptest.NewRequest("GET", "/reports/stalled?days=-1", nil))
+	if w.Code != 400 {
+		t.Errorf("expected 400 got %d", w.Code)
+	}
+}
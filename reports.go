@@ -1,18 +1,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"html/template"
 	"log"
 	"math"
 	"net/http"
 	"net/url"
-	"os"
 	"sort"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/storage"
 	"github.com/gosimple/slug"
 	"github.com/jehiah/legislator/db"
 )
@@ -35,6 +34,25 @@ type CommitteeSponsorship struct {
 	CommitteeMembers      int
 }
 
+// NewCommitteeSponsorship counts the sponsors of l that are members (by ID) of the committee it's referred to
+func NewCommitteeSponsorship(l Legislation, members map[int]bool) CommitteeSponsorship {
+	c := CommitteeSponsorship{
+		BodyName:         l.BodyName,
+		CommitteeMembers: len(members),
+		Sponsors:         len(l.Sponsors),
+	}
+	for _, s := range l.Sponsors {
+		if s.ID == 0 {
+			continue // i.e. BP, PA
+		}
+		c.CouncilmemberSponsors++
+		if members[s.ID] {
+			c.CommitteeSponsors++
+		}
+	}
+	return c
+}
+
 func (c CommitteeSponsorship) Majority() bool {
 	return c.CouncilmemberSponsors >= 26
 }
@@ -48,39 +66,13 @@ func (c CommitteeSponsorship) CommitteeString() string {
 	return fmt.Sprintf("%d of %d", c.CommitteeSponsors, c.CommitteeMembers)
 }
 
-// ReportMostSponsored returns the list of legislation changes /recent
-func (a *App) ReportMostSponsored(w http.ResponseWriter, r *http.Request) {
-	templateName := "report_most_sponsored.html"
-
-	type Page struct {
-		Page        string
-		SubPage     string
-		LastSync    LastSync
-		Legislation LegislationList
-		Committees  []string
-		// Sessions    []Session
-	}
-	body := Page{
-		Page:    "reports",
-		SubPage: "most_sponsored",
-		// Sessions: Sessions[:3],
-
-	}
-
-	err := a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-
+// getCommitteeMembers returns the IDs of the current members of each committee by BodyName
+func (a *App) getCommitteeMembers(ctx context.Context) (map[string]map[int]bool, error) {
 	committeeMembers := make(map[string]map[int]bool)
 	var people []db.Person
-	err = a.getJSONFile(r.Context(), "build/people_all.json", &people)
+	err := a.getJSONFile(ctx, "build/people_all.json", &people)
 	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
+		return nil, err
 	}
 	now := time.Now()
 	startDate := CurrentSession.StartDate()
@@ -107,41 +99,62 @@ func (a *App) ReportMostSponsored(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	return committeeMembers, nil
+}
+
+// ReportMostSponsored returns legislation in the current session by number of sponsors /reports/most_sponsored
+func (a *App) ReportMostSponsored(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	templateName := "report_most_sponsored.html"
+
+	type Page struct {
+		Page        string
+		SubPage     string
+		LastSync    LastSync
+		Legislation LegislationList
+		Committees  []string
+		IntroType   string
+		// Sessions    []Session
+	}
+	body := Page{
+		Page:    "reports",
+		SubPage: "most_sponsored",
+		// Sessions: Sessions[:3],
+
+	}
+	var introTypes []string
+	var err error
+	body.IntroType, introTypes, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	committeeMembers, err := a.getCommitteeMembers(r.Context())
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
 
 	t := newTemplate(a.templateFS, templateName, template.FuncMap{
 		"CommitteeSponsors": func(l Legislation) CommitteeSponsorship {
-			m := committeeMembers[l.BodyName]
-			c := CommitteeSponsorship{
-				BodyName:         l.BodyName,
-				CommitteeMembers: len(m),
-				Sponsors:         len(l.Sponsors),
-			}
-			for _, s := range l.Sponsors {
-				if s.ID == 0 {
-					continue // i.e. BP, PA
-				}
-				c.CouncilmemberSponsors++
-				if m[s.ID] {
-					c.CommitteeSponsors++
-				}
-			}
-			return c
+			return NewCommitteeSponsorship(l, committeeMembers[l.BodyName])
 		},
 	})
 
-	// get all the years for the legislative session
-	for year := CurrentSession.StartYear; year <= CurrentSession.EndYear && year <= time.Now().Year(); year++ {
-		var l []Legislation
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
-		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
-				continue
-			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
-		}
-		body.Legislation = append(body.Legislation, l...)
+	body.Legislation, err = a.getSessionLegislation(r.Context(), CurrentSession, introTypes, "")
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
 	}
 	sort.Slice(body.Legislation, func(i, j int) bool { return len(body.Legislation[i].Sponsors) > len(body.Legislation[j].Sponsors) })
 
@@ -157,8 +170,51 @@ func (a *App) ReportMostSponsored(w http.ResponseWriter, r *http.Request) {
 	}
 	sort.Strings(body.Committees)
 
-	w.Header().Set("content-type", "text/html")
 	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		report := MostSponsoredReport{Rows: []MostSponsoredRow{}}
+		// the committee filter is applied client side for HTML
+		selectedCommittee := r.Form.Get("committee")
+		var filters map[string]string
+		if selectedCommittee != "" {
+			filters = map[string]string{"committee": selectedCommittee}
+		}
+		filters = withIntroType(filters, body.IntroType)
+		for _, l := range body.Legislation {
+			if selectedCommittee != "" && slug.Make(TrimCommittee(l.BodyName)) != selectedCommittee {
+				continue
+			}
+			c := NewCommitteeSponsorship(l, committeeMembers[l.BodyName])
+			action, date := l.RecentAction()
+			report.Rows = append(report.Rows, MostSponsoredRow{
+				File:              l.File,
+				Name:              l.Name,
+				StatusName:        l.StatusName,
+				BodyName:          l.BodyName,
+				IntroDate:         l.IntroDate,
+				RecentAction:      action,
+				RecentDate:        date,
+				PrimarySponsor:    l.PrimarySponsor(),
+				CommitteeMembers:  c.CommitteeMembers,
+				CommitteeSponsors: c.CommitteeSponsors,
+				Sponsors:          c.Sponsors,
+				CouncilSponsors:   c.CouncilmemberSponsors,
+				Majority:          c.Majority(),
+				SuperMajority:     c.SuperMajority(),
+				CommitteeMajority: c.CommitteeMajority(),
+			})
+		}
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "most_sponsored",
+			Session:  CurrentSession.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  filters,
+			Data:     report,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
 	a.addExpireHeaders(w, cacheTTL)
 	err = t.ExecuteTemplate(w, templateName, body)
 	if err != nil {
@@ -175,20 +231,14 @@ func (a *App) ReportBySession(w http.ResponseWriter, r *http.Request) {
 
 	t := newTemplate(a.templateFS, template)
 
-	type Row struct {
-		Date   string
-		Count  int
-		Status string
-		Time   time.Time `json:"-"`
-		Last   bool      `json:"Last,omitempty"`
-	}
 	type Page struct {
-		Page     string
-		SubPage  string
-		LastSync LastSync
-		Data     []Row
-		Session  Session
-		Sessions []Session
+		Page      string
+		SubPage   string
+		LastSync  LastSync
+		Data      []SessionReportRow
+		Session   Session
+		Sessions  []Session
+		IntroType string
 	}
 	body := Page{
 		Page:     "reports",
@@ -201,67 +251,116 @@ func (a *App) ReportBySession(w http.ResponseWriter, r *http.Request) {
 			body.Session = s
 		}
 	}
+	var err error
+	body.IntroType, _, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
 
-	introduced, hearing, approved, enacted := make(map[time.Time]int), make(map[time.Time]int), make(map[time.Time]int), make(map[time.Time]int)
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
 
-	err := a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	report, err := a.getSessionReport(r.Context(), body.Session, body.IntroType)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "Internal Server Error", 500)
 		return
 	}
+	var tomorrow time.Time
+	if body.Session == CurrentSession {
+		// add current values as 'tomorrow'. This ensures todays values have a step to tomorrow
+		tomorrow = time.Now().In(americaNewYork).Truncate(time.Hour*24).AddDate(0, 0, 1)
+	}
+	body.Data = report.chartRows(tomorrow)
 
-	// get all the years for the legislative session
-	for year := body.Session.StartYear; year <= body.Session.EndYear && year <= time.Now().Year(); year++ {
-		var l []Legislation
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
-		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
-				continue
-			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "session",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  withIntroType(nil, body.IntroType),
+			Data:     SessionReport{Rows: body.Data},
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// getSessionReport returns the snapshot for a session; reports including resolutions are always computed
+func (a *App) getSessionReport(ctx context.Context, s Session, introType string) (SessionReport, error) {
+	var report SessionReport
+	introTypes, err := IntroTypes(introType)
+	if err != nil {
+		return report, err
+	}
+	if introType == "introduction" {
+		if ok, err := a.getReportSnapshot(ctx, "session", s, &report); ok || err != nil {
+			return report, err
 		}
-		for _, ll := range l {
-			if ll.StatusName == "Withdrawn" {
-				continue
-			}
-			day := ll.IntroDate.In(americaNewYork).Truncate(time.Hour * 24)
-			introduced[day] = introduced[day] + 1
+	}
+	return a.computeSessionReport(ctx, s, introTypes)
+}
 
-			seen := make(map[string]bool)
-			for _, h := range ll.History {
-				if seen[h.Action] {
-					continue
-				}
-				seen[h.Action] = true // only track first hearing
-				day := h.Date.In(americaNewYork).Truncate(time.Hour * 24)
+// computeSessionReport returns a running total of bills introduced, heard, passed and enacted in a session
+func (a *App) computeSessionReport(ctx context.Context, s Session, introTypes []string) (SessionReport, error) {
+	introduced, hearing, approved, enacted := make(map[time.Time]int), make(map[time.Time]int), make(map[time.Time]int), make(map[time.Time]int)
 
-				switch h.Action {
-				// use IntroDate directly; some bills don't have a matching action 0407-2022
-				// case "Introduced by Council":
-				// 	introduced[day] = introduced[day] + 1
-				case "Hearing Held by Committee", "Hearing on P-C Item by Comm":
-					hearing[day] = hearing[day] + 1
-				case "Approved by Council":
-					approved[day] = approved[day] + 1
-				case "City Charter Rule Adopted", "Signed Into Law by Mayor",
-					"Overridden by Council": // possible after "Vetoed by Mayor" (See Int 1208-2013)
-					enacted[day] = enacted[day] + 1
-				default:
-					continue
-				}
+	l, err := a.getSessionLegislation(ctx, s, introTypes, "")
+	if err != nil {
+		return SessionReport{}, err
+	}
+	for _, ll := range l {
+		if ll.StatusName == "Withdrawn" {
+			continue
+		}
+		day := ll.IntroDate.In(americaNewYork).Truncate(time.Hour * 24)
+		introduced[day] = introduced[day] + 1
+
+		seen := make(map[string]bool)
+		for _, h := range ll.History {
+			if seen[h.Action] {
+				continue
+			}
+			seen[h.Action] = true // only track first hearing
+			day := h.Date.In(americaNewYork).Truncate(time.Hour * 24)
+
+			switch h.Action {
+			// use IntroDate directly; some bills don't have a matching action 0407-2022
+			// case "Introduced by Council":
+			// 	introduced[day] = introduced[day] + 1
+			case "Hearing Held by Committee", "Hearing on P-C Item by Comm":
+				hearing[day] = hearing[day] + 1
+			case "Approved by Council":
+				approved[day] = approved[day] + 1
+			case "City Charter Rule Adopted", "Signed Into Law by Mayor",
+				"Overridden by Council": // possible after "Vetoed by Mayor" (See Int 1208-2013)
+				enacted[day] = enacted[day] + 1
+			default:
+				continue
 			}
 		}
 	}
 
-	today := time.Now().In(americaNewYork).Truncate(time.Hour * 24)
+	report := SessionReport{Rows: []SessionReportRow{}}
 	for i, d := range []map[time.Time]int{introduced, hearing, approved, enacted} {
 		status := []string{"Introduced", "Hearing Held", "Passed Council", "Enacted"}[i]
-		var data []Row
+		var data []SessionReportRow
 		for date, count := range d {
-			data = append(data, Row{Time: date, Date: date.Format(time.RFC3339), Count: count, Status: status})
+			data = append(data, SessionReportRow{Time: date, Date: date.Format(time.RFC3339), Count: count, Status: status})
 		}
 		sort.Slice(data, func(i, j int) bool { return data[i].Time.Before(data[j].Time) })
 		carry := 0
@@ -269,33 +368,26 @@ func (a *App) ReportBySession(w http.ResponseWriter, r *http.Request) {
 			data[i].Count += carry
 			carry += v.Count
 		}
+		report.Rows = append(report.Rows, data...)
+	}
+	return report, nil
+}
 
-		if body.Session == CurrentSession {
-			// add current values as 'tomorrow'. This ensures todays values have a step to tomorrow
-			if len(data) > 0 {
-				last := data[len(data)-1]
-				// show tomorrow
-				tomorrow := today.AddDate(0, 0, 1)
-				data = append(data, Row{Time: tomorrow, Date: tomorrow.Format(time.RFC3339), Count: last.Count, Status: last.Status})
-			}
+// chartRows flags the last row for each Status. When extend is set the last
+// value for each Status is repeated at that time.
+func (r SessionReport) chartRows(extend time.Time) []SessionReportRow {
+	o := []SessionReportRow{}
+	for i, row := range r.Rows {
+		o = append(o, row)
+		if i+1 < len(r.Rows) && r.Rows[i+1].Status == row.Status {
+			continue
 		}
-		if len(data) > 0 {
-			data[len(data)-1].Last = true
+		if !extend.IsZero() {
+			o = append(o, SessionReportRow{Time: extend, Date: extend.Format(time.RFC3339), Count: row.Count, Status: row.Status})
 		}
-
-		body.Data = append(body.Data, data...)
-
-	}
-
-	w.Header().Set("content-type", "text/html")
-	cacheTTL := time.Minute * 15
-	a.addExpireHeaders(w, cacheTTL)
-	err = t.ExecuteTemplate(w, template, body)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
+		o[len(o)-1].Last = true
 	}
+	return o
 }
 
 // ReportSimilarity shows how similar CMs are
@@ -305,62 +397,134 @@ func (a *App) ReportSimilarity(w http.ResponseWriter, r *http.Request) {
 
 	t := newTemplate(a.templateFS, template)
 
-	type Row struct {
-		Person
-
-		ExpectedSponsors int
-		Sponsors         int
-		SponsorPercent   float64
-		ExpectedVotes    int
-		Votes            int
-		VotePercent      float64
-	}
-	data := make(map[int]*Row)
-
 	type Page struct {
-		Page     string
-		SubPage  string
-		LastSync LastSync
-		Data     []Row
-		Session  Session
-		Sessions []Session
-		People   []db.Person
-		Person   db.Person
-		Matrix   map[int]*Row
-		Names    []string
+		Page      string
+		SubPage   string
+		LastSync  LastSync
+		Session   Session
+		Sessions  []Session
+		People    []SimilarityRow
+		Person    db.PersonReference
+		Self      SimilarityRow
+		Data      []SimilarityRow
+		IntroType string
 	}
 	body := Page{
 		Page:     "reports",
 		SubPage:  "similarity",
 		Session:  CurrentSession,
 		Sessions: Sessions,
-		Matrix:   data,
 	}
 	for _, s := range Sessions {
 		if s.String() == r.Form.Get("session") {
 			body.Session = s
 		}
 	}
+	var err error
+	body.IntroType, _, err = introTypeParam(r)
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
 
-	// var metadata []PersonMetadata
-	// err = a.getJSONFile(r.Context(), "build/people_metadata.json", &metadata)
-	// if err != nil {
-	// 	log.Print(err)
-	// 	http.Error(w, "Internal Server Error", 500)
-	// 	return
-	// }
-	// metadataLookup := make(map[int]PersonMetadata)
-	// for _, m := range metadata {
-	// 	metadataLookup[m.ID] = metadataLookup[]
-	// }
+	reports, err := a.getSimilarityReports(r.Context(), body.Session, body.IntroType)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if len(reports) == 0 {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	var report SimilarityReport
+	for _, rr := range reports {
+		if rr.Councilmember.Slug == r.Form.Get("councilmember") {
+			report = rr
+			break
+		}
+	}
 
-	var people []db.Person
-	err := a.getJSONFile(r.Context(), "build/people_all.json", &people)
+	if report.Councilmember.Slug == "" {
+		if r.Form.Get("councilmember") != "" {
+			http.Error(w, "Not Found", 404)
+			return
+		}
+		params := &url.Values{
+			"councilmember": []string{reports[0].Councilmember.Slug},
+			"session":       []string{body.Session.String()},
+		}
+		if body.IntroType != "introduction" {
+			params.Set("type", body.IntroType)
+		}
+		http.Redirect(w, r, r.URL.Path+"?"+params.Encode(), 302)
+		return
+	}
+	body.Person = report.Councilmember
+	body.Data = report.Rows
+	body.People = report.Rows
+	for _, row := range report.Rows {
+		if row.Councilmember.ID == report.Councilmember.ID {
+			body.Self = row
+		}
+	}
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "similarity",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  withIntroType(map[string]string{"councilmember": body.Person.Slug}, body.IntroType),
+			Data:     report,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "Internal Server Error", 500)
 		return
 	}
+
+}
+
+// getSimilarityReports returns the snapshot for a session; reports including resolutions are always computed
+func (a *App) getSimilarityReports(ctx context.Context, s Session, introType string) ([]SimilarityReport, error) {
+	var reports []SimilarityReport
+	introTypes, err := IntroTypes(introType)
+	if err != nil {
+		return nil, err
+	}
+	if introType == "introduction" {
+		if ok, err := a.getReportSnapshot(ctx, "similarity", s, &reports); ok || err != nil {
+			return reports, err
+		}
+	}
+	return a.computeSimilarityReports(ctx, s, introTypes)
+}
+
+// computeSimilarityReports compares sponsorship and votes on introTypes between every pair of council members in a session
+//
+// One SimilarityReport is returned for each council member
+func (a *App) computeSimilarityReports(ctx context.Context, s Session, introTypes []string) ([]SimilarityReport, error) {
+	var people []db.Person
+	err := a.getJSONFile(ctx, "build/people_all.json", &people)
+	if err != nil {
+		return nil, err
+	}
+	var members []Person
+	index := make(map[int]int) // person ID -> members index
 	for _, p := range people {
 		include := false
 		for _, or := range p.OfficeRecords {
@@ -369,81 +533,55 @@ func (a *App) ReportSimilarity(w http.ResponseWriter, r *http.Request) {
 				continue
 			case or.MemberType == "PRIMARY PUBLIC ADVOCATE":
 				continue
-			case !body.Session.Overlaps(or.Start, or.End):
+			case !s.Overlaps(or.Start, or.End):
 				continue
 			}
 			include = true
 			break
 		}
 		if include {
-			body.People = append(body.People, p)
-			data[p.ID] = &Row{Person: Person{Person: p}}
+			index[p.ID] = len(members)
+			members = append(members, Person{Person: p})
 		}
 	}
 
-	for _, p := range body.People {
-		if p.Slug == r.Form.Get("councilmember") {
-			body.Person = p
-			break
+	n := len(members)
+	matrix := func() [][]int {
+		m := make([][]int, n)
+		for i := range m {
+			m[i] = make([]int, n)
 		}
+		return m
 	}
+	// sponsors[i][j] is the number of bills sponsored by i that j also sponsored
+	// votes[i][j] is the number of votes (of expectedVotes[i][j]) where j voted the same as i
+	sponsors, votes, expectedVotes := matrix(), matrix(), matrix()
 
-	if body.Person.Slug == "" {
-		if r.Form.Get("councilmember") != "" {
-			http.Error(w, "Not Found", 404)
-			return
-		}
-		params := &url.Values{
-			"councilmember": []string{body.People[0].Slug},
-			"session":       []string{body.Session.String()},
-		}
-		http.Redirect(w, r, "/reports/similarity?"+params.Encode(), 302)
-		return
+	l, err := a.getSessionLegislation(ctx, s, introTypes, "_votes")
+	if err != nil {
+		return nil, err
 	}
-
-	// get all the years for the legislative session
-	for year := body.Session.StartYear; year <= body.Session.EndYear && year <= time.Now().Year(); year++ {
-		var l []Legislation
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d_votes.json", year), &l)
-		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
-				continue
+	for _, ll := range l {
+		// count sponsorship; skip sponsorships by BP's
+		var sponsorIndexes []int
+		for _, s := range ll.Sponsors {
+			if i, ok := index[s.ID]; ok {
+				sponsorIndexes = append(sponsorIndexes, i)
 			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
 		}
-		for _, ll := range l {
-			// count sponsorship
-			countSponsorship := false
-			for _, s := range ll.Sponsors {
-				if s.ID == body.Person.ID {
-					countSponsorship = true
-					break
-				}
+		for _, i := range sponsorIndexes {
+			for _, j := range sponsorIndexes {
+				sponsors[i][j]++
 			}
-			if countSponsorship {
-				for _, s := range ll.Sponsors {
-					r, ok := data[s.ID]
-					if !ok {
-						continue // skip sponsorships by BP's
-					}
-					r.Sponsors++
-				}
-			}
-
-			// walk votes backwards; use the first one
-			for i := len(ll.History) - 1; i >= 0; i-- {
-				h := ll.History[i]
-				// find desired vote
-				var desired = 0
+		}
 
-				for _, v := range h.Votes {
-					if v.ID == body.Person.ID {
-						desired = v.VoteID
-					}
+		for _, h := range ll.History {
+			for _, desired := range h.Votes {
+				i, ok := index[desired.ID]
+				if !ok {
+					continue
 				}
-				switch desired {
+				switch desired.VoteID {
 				case 12, 15:
 					// Negative, Affirmative
 				default:
@@ -462,46 +600,46 @@ func (a *App) ReportSimilarity(w http.ResponseWriter, r *http.Request) {
 						// Maternity, Paternity, Jury Duty, Medical, Bereavement, Conflict, Suspended, 	Non-voting, Excused, Parental
 						continue
 					}
-					if data[v.ID] == nil {
+					j, ok := index[v.ID]
+					if !ok {
 						// vote from someone no longer in session
 						continue
 					}
-					data[v.ID].ExpectedVotes++
-					if v.VoteID == desired {
-						data[v.ID].Votes++
+					expectedVotes[i][j]++
+					if v.VoteID == desired.VoteID {
+						votes[i][j]++
 					}
 				}
 			}
 		}
 	}
-	expectedSponsors := data[body.Person.ID].Sponsors
-	for _, r := range data {
-		r.ExpectedSponsors = expectedSponsors
-		if r.ExpectedSponsors > 0 {
-			r.SponsorPercent = (float64(r.Sponsors) / float64(r.ExpectedSponsors)) * 100
+
+	var reports []SimilarityReport
+	for i, p := range members {
+		report := SimilarityReport{
+			Councilmember: db.PersonReference{ID: p.ID(), Slug: p.Person.Slug, FullName: p.FullName},
+			Rows:          []SimilarityRow{},
 		}
-		if r.ExpectedVotes > 0 {
-			r.VotePercent = (float64(r.Votes) / float64(r.ExpectedVotes)) * 100
+		for j, pp := range members {
+			row := SimilarityRow{
+				Councilmember:    db.PersonReference{ID: pp.ID(), Slug: pp.Person.Slug, FullName: pp.FullName},
+				Party:            pp.PartyShort(),
+				ExpectedSponsors: sponsors[i][i],
+				Sponsors:         sponsors[i][j],
+				ExpectedVotes:    expectedVotes[i][j],
+				Votes:            votes[i][j],
+			}
+			if row.ExpectedSponsors > 0 {
+				row.SponsorPercent = (float64(row.Sponsors) / float64(row.ExpectedSponsors)) * 100
+			}
+			if row.ExpectedVotes > 0 {
+				row.VotePercent = (float64(row.Votes) / float64(row.ExpectedVotes)) * 100
+			}
+			report.Rows = append(report.Rows, row)
 		}
+		reports = append(reports, report)
 	}
-
-	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-
-	w.Header().Set("content-type", "text/html")
-	cacheTTL := time.Minute * 15
-	a.addExpireHeaders(w, cacheTTL)
-	err = t.ExecuteTemplate(w, template, body)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-
+	return reports, nil
 }
 
 // ReportCouncilmembers shows the legislative activity of each councilmember
@@ -511,27 +649,11 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 
 	t := newTemplate(a.templateFS, template)
 
-	type Row struct {
-		Person       db.PersonReference
-		OfficeRecord db.OfficeRecord
-
-		IntroIntro   int
-		IntroHearing int
-		IntroPassed  int
-		IntroEnacted int
-		IntroVeto    int
-
-		SponsorIntro   int
-		SponsorHearing int
-		SponsorPassed  int
-		SponsorEnacted int
-		SponsorVeto    int
-	}
 	type Page struct {
 		Page             string
 		SubPage          string
 		LastSync         LastSync
-		Data             []Row
+		Data             []CouncilmemberReportRow
 		Session          Session
 		Sessions         []Session
 		Committees       []string
@@ -554,18 +676,72 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 	}
 	selectedCommittee := r.Form.Get("committee")
 
+	err := a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+
+	report, err := a.getCouncilmembersReport(r.Context(), body.Session, selectedCommittee)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	body.Data = report.Rows
+	body.Committees = report.Committees
+
+	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		var filters map[string]string
+		if selectedCommittee != "" {
+			filters = map[string]string{"committee": selectedCommittee}
+		}
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "councilmembers",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Filters:  filters,
+			Data:     report,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+// getCouncilmembersReport returns the snapshot for a session; reports filtered by committee are always computed
+func (a *App) getCouncilmembersReport(ctx context.Context, s Session, committee string) (CouncilmembersReport, error) {
+	if committee == "" {
+		var report CouncilmembersReport
+		if ok, err := a.getReportSnapshot(ctx, "councilmembers", s, &report); ok || err != nil {
+			return report, err
+		}
+	}
+	return a.computeCouncilmembersReport(ctx, s, committee)
+}
+
+// computeCouncilmembersReport counts bills introduced and sponsored by each council member
+// optionally limited to bills referred to a committee (slug)
+func (a *App) computeCouncilmembersReport(ctx context.Context, s Session, selectedCommittee string) (CouncilmembersReport, error) {
 	peopleOfficeRecord := make(map[string]db.OfficeRecord)
 	if selectedCommittee != "" {
 		var people []db.Person
-		err := a.getJSONFile(r.Context(), "build/e.json", &people)
+		err := a.getJSONFile(ctx, "build/people_all.json", &people)
 		if err != nil {
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+			return CouncilmembersReport{}, err
 		}
 		for _, p := range people {
 			for _, or := range p.OfficeRecords {
-				if !body.Session.Overlaps(or.Start, or.End) {
+				if !s.Overlaps(or.Start, or.End) {
 					continue
 				}
 				shortCommittee := slug.Make(TrimCommittee(or.BodyName))
@@ -577,27 +753,18 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	err := a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-
-	data := make(map[string]*Row)
+	data := make(map[string]*CouncilmemberReportRow)
 	c := make(map[string]bool)
 
 	// get all the years for the legislative session
-	for year := body.Session.StartYear; year <= body.Session.EndYear && year <= time.Now().Year(); year++ {
+	for year := s.StartYear; year <= s.EndYear && year <= time.Now().Year(); year++ {
 		var l []Legislation
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
+		err := a.getJSONFile(ctx, fmt.Sprintf("build/%d.json", year), &l)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+			return CouncilmembersReport{}, err
 		}
 		for _, ll := range l {
 			if ll.StatusName == "Withdrawn" {
@@ -612,7 +779,7 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 
-			var hasHearing, hasPassed, hasEnacted bool
+			var hasHearing, hasPassed, hasEnacted, hasVeto bool
 
 			seen := make(map[string]bool)
 			for _, h := range ll.History {
@@ -628,6 +795,8 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 				case "City Charter Rule Adopted", "Signed Into Law by Mayor",
 					"Overridden by Council": // possible after "Vetoed by Mayor" (See Int 1208-2013)
 					hasEnacted = true
+				case "Vetoed by Mayor":
+					hasVeto = true
 				default:
 					continue
 				}
@@ -635,7 +804,7 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 			for i, s := range ll.Sponsors {
 				r, ok := data[s.Slug]
 				if !ok {
-					r = &Row{Person: s, OfficeRecord: peopleOfficeRecord[s.Slug]}
+					r = &CouncilmemberReportRow{Person: s, OfficeRecord: peopleOfficeRecord[s.Slug]}
 					data[s.Slug] = r
 				}
 				if i == 0 {
@@ -649,7 +818,9 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 					if hasEnacted {
 						r.IntroEnacted += 1
 					}
-					// TODO veto?
+					if hasVeto {
+						r.IntroVeto += 1
+					}
 				} else {
 					r.SponsorIntro += 1
 					if hasHearing {
@@ -661,35 +832,30 @@ func (a *App) ReportCouncilmembers(w http.ResponseWriter, r *http.Request) {
 					if hasEnacted {
 						r.SponsorEnacted += 1
 					}
+					if hasVeto {
+						r.SponsorVeto += 1
+					}
 				}
 			}
 		}
 	}
 
+	report := CouncilmembersReport{Rows: []CouncilmemberReportRow{}}
 	for _, r := range data {
-		body.Data = append(body.Data, *r)
+		report.Rows = append(report.Rows, *r)
 	}
-	sort.Slice(body.Data, func(i, j int) bool {
-		if body.Data[i].IntroIntro == body.Data[j].IntroIntro {
-			return strings.Compare(body.Data[i].Person.FullName, body.Data[j].Person.FullName) == -1
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].IntroIntro == report.Rows[j].IntroIntro {
+			return strings.Compare(report.Rows[i].Person.FullName, report.Rows[j].Person.FullName) == -1
 		}
 
-		return body.Data[i].IntroIntro > body.Data[j].IntroIntro
+		return report.Rows[i].IntroIntro > report.Rows[j].IntroIntro
 	})
 	for b, _ := range c {
-		body.Committees = append(body.Committees, TrimCommittee(b))
-	}
-	sort.Strings(body.Committees)
-
-	w.Header().Set("content-type", "text/html")
-	cacheTTL := time.Minute * 15
-	a.addExpireHeaders(w, cacheTTL)
-	err = t.ExecuteTemplate(w, template, body)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
+		report.Committees = append(report.Committees, TrimCommittee(b))
 	}
+	sort.Strings(report.Committees)
+	return report, nil
 }
 
 // ReportCommittees shows the legislative activity of each committee
@@ -699,24 +865,11 @@ func (a *App) ReportCommittees(w http.ResponseWriter, r *http.Request) {
 
 	t := newTemplate(a.templateFS, template)
 
-	type Row struct {
-		Committee         string
-		BillTotal         int
-		BillHearing       int
-		BillCommitteeVote int
-		BillPassedCouncil int
-		BillEnacted       int
-		Hearings          int
-
-		HearingDates      map[string]bool
-		OversightHearings int
-	}
-
 	type Page struct {
 		Page     string
 		SubPage  string
 		LastSync LastSync
-		Data     []Row
+		Data     []CommitteeReportRow
 		Session  Session
 		Sessions []Session
 		// Committees       []string ?
@@ -746,29 +899,64 @@ func (a *App) ReportCommittees(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := make(map[string]*Row)
-	c := make(map[string]bool)
+	report, err := a.getCommitteesReport(r.Context(), body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	body.Data = report.Rows
+
+	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "committees",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Data:     report,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
+
+func (a *App) getCommitteesReport(ctx context.Context, s Session) (CommitteesReport, error) {
+	var report CommitteesReport
+	if ok, err := a.getReportSnapshot(ctx, "committees", s, &report); ok || err != nil {
+		return report, err
+	}
+	return a.computeCommitteesReport(ctx, s)
+}
+
+// computeCommitteesReport counts the bills referred to each committee and their progress
+func (a *App) computeCommitteesReport(ctx context.Context, s Session) (CommitteesReport, error) {
+	data := make(map[string]*CommitteeReportRow)
 
 	// get all the years for the legislative session
-	for year := body.Session.StartYear; year <= body.Session.EndYear && year <= time.Now().Year(); year++ {
+	for year := s.StartYear; year <= s.EndYear && year <= time.Now().Year(); year++ {
 		var l []Legislation
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/%d.json", year), &l)
+		err := a.getJSONFile(ctx, fmt.Sprintf("build/%d.json", year), &l)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+			return CommitteesReport{}, err
 		}
 		for _, ll := range l {
 			if ll.StatusName == "Withdrawn" {
 				continue
 			}
-			c[ll.BodyName] = true
 			d := data[ll.BodyName]
 			if d == nil {
-				d = &Row{
+				d = &CommitteeReportRow{
 					Committee:    TrimCommittee(ll.BodyName),
 					HearingDates: make(map[string]bool),
 				}
@@ -811,14 +999,12 @@ func (a *App) ReportCommittees(w http.ResponseWriter, r *http.Request) {
 
 		// Count hearings: any event for this committee w/ a roll call
 		var events []db.Event
-		err = a.getJSONFile(r.Context(), fmt.Sprintf("build/events_attendance_%d.json", year), &events)
+		err = a.getJSONFile(ctx, fmt.Sprintf("build/events_attendance_%d.json", year), &events)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+			return CommitteesReport{}, err
 		}
 
 		for _, e := range events {
@@ -836,27 +1022,14 @@ func (a *App) ReportCommittees(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	report := CommitteesReport{Rows: []CommitteeReportRow{}}
 	for _, r := range data {
-		body.Data = append(body.Data, *r)
+		report.Rows = append(report.Rows, *r)
 	}
-	sort.Slice(body.Data, func(i, j int) bool {
-		return strings.Compare(body.Data[i].Committee, body.Data[j].Committee) == -1
+	sort.Slice(report.Rows, func(i, j int) bool {
+		return strings.Compare(report.Rows[i].Committee, report.Rows[j].Committee) == -1
 	})
-
-	// for b, _ := range c {
-	// 	body.Committees = append(body.Committees, TrimCommitte(b)
-	// }
-	// sort.Strings(body.Committees)
-
-	w.Header().Set("content-type", "text/html")
-	cacheTTL := time.Minute * 15
-	a.addExpireHeaders(w, cacheTTL)
-	err = t.ExecuteTemplate(w, template, body)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
+	return report, nil
 }
 
 // ReportAttendance shows summary of roll calls
@@ -866,31 +1039,15 @@ func (a *App) ReportAttendance(w http.ResponseWriter, r *http.Request) {
 
 	t := newTemplate(a.templateFS, template)
 
-	type Row struct {
-		Person
-
-		ExpectedCouncilRollCall   int
-		CouncilRollCall           int
-		CouncilPercent            float64
-		ExpectedCommitteeRollCall int
-		CommitteeRollCall         int
-		CommitteePercent          float64
-	}
-	data := make(map[int]*Row)
-
 	type Page struct {
 		Page                string
 		SubPage             string
 		LastSync            LastSync
 		CountedEvents       int
 		FullCouncilEvents   int
-		Data                []Row
+		Data                []AttendanceRow
 		Session             Session
 		Sessions            []Session
-		People              []db.Person
-		Person              db.Person
-		Matrix              map[int]*Row
-		Names               []string
 		MinCouncilPercent   float64
 		MinCommitteePercent float64
 	}
@@ -899,7 +1056,6 @@ func (a *App) ReportAttendance(w http.ResponseWriter, r *http.Request) {
 		SubPage:             "attendance",
 		Session:             CurrentSession,
 		Sessions:            Sessions,
-		Matrix:              data,
 		MinCouncilPercent:   100,
 		MinCommitteePercent: 100,
 	}
@@ -909,25 +1065,90 @@ func (a *App) ReportAttendance(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// var metadata []PersonMetadata
-	// err = a.getJSONFile(r.Context(), "build/people_metadata.json", &metadata)
-	// if err != nil {
-	// 	log.Print(err)
-	// 	http.Error(w, "Internal Server Error", 500)
-	// 	return
-	// }
-	// metadataLookup := make(map[int]PersonMetadata)
-	// for _, m := range metadata {
-	// 	metadataLookup[m.ID] = metadataLookup[]
-	// }
+	report, err := a.getAttendanceReport(r.Context(), body.Session)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	body.Data = report.Rows
+	body.CountedEvents = report.CountedEvents
+	body.FullCouncilEvents = report.FullCouncilEvents
+	for _, r := range report.Rows {
+		if r.ExpectedCouncilRollCall > 0 && r.CouncilPercent < body.MinCouncilPercent {
+			body.MinCouncilPercent = math.Floor(r.CouncilPercent)
+		}
+		if r.ExpectedCommitteeRollCall > 0 && r.CommitteePercent < body.MinCommitteePercent {
+			body.MinCommitteePercent = math.Floor(r.CommitteePercent)
+		}
+	}
+
+	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
 
-	var people []db.Person
-	err := a.getJSONFile(r.Context(), "build/people_all.json", &people)
+	cacheTTL := time.Minute * 15
+	if wantsJSON(r) || wantsCSV(r) {
+		a.writeReport(w, r, cacheTTL, ReportResponse{
+			Report:   "attendance",
+			Session:  body.Session.String(),
+			LastSync: body.LastSync.LastRun,
+			Data:     report,
+		})
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	a.addExpireHeaders(w, cacheTTL)
+	err = t.ExecuteTemplate(w, template, body)
 	if err != nil {
 		log.Print(err)
 		http.Error(w, "Internal Server Error", 500)
 		return
 	}
+
+}
+
+func (a *App) getAttendanceReport(ctx context.Context, s Session) (AttendanceReport, error) {
+	var report AttendanceReport
+	if ok, err := a.getReportSnapshot(ctx, "attendance", s, &report); ok || err != nil {
+		return report, err
+	}
+	return a.computeAttendanceReport(ctx, s)
+}
+
+// rollCallExcused returns true for roll call values that don't count towards attendance
+// (13 is Present, 16 Absent)
+func rollCallExcused(valueID int) bool {
+	switch valueID {
+	case 4, 22, 23, 43, 44, 45, 46, 65:
+		// 4: Excused
+		// 22: Maternity
+		// 23: Suspended
+		// 43: Conflict
+		// 44: Paternity
+		// 46: Medical
+		// 45: Jury Duty
+		// 65: Bereavement
+		return true
+	}
+	return false
+}
+
+// computeAttendanceReport summarizes the roll calls for each council member in a session
+func (a *App) computeAttendanceReport(ctx context.Context, s Session) (AttendanceReport, error) {
+	var report AttendanceReport
+
+	var people []db.Person
+	err := a.getJSONFile(ctx, "build/people_all.json", &people)
+	if err != nil {
+		return report, err
+	}
+	data := make(map[int]*AttendanceRow)
+	var order []int
 	for _, p := range people {
 		include := false
 		for _, or := range p.OfficeRecords {
@@ -936,30 +1157,31 @@ func (a *App) ReportAttendance(w http.ResponseWriter, r *http.Request) {
 				continue
 			case or.MemberType == "PRIMARY PUBLIC ADVOCATE":
 				continue
-			case !body.Session.Overlaps(or.Start, or.End):
+			case !s.Overlaps(or.Start, or.End):
 				continue
 			}
 			include = true
 			break
 		}
 		if include {
-			body.People = append(body.People, p)
-			data[p.ID] = &Row{Person: Person{Person: p}}
+			order = append(order, p.ID)
+			data[p.ID] = &AttendanceRow{
+				Councilmember: db.PersonReference{ID: p.ID, Slug: p.Slug, FullName: p.FullName},
+				Party:         Person{Person: p}.PartyShort(),
+			}
 		}
 	}
 
 	currentYear := time.Now().Year()
 	// get all the years for the legislative session
-	for year := body.Session.StartYear; year <= body.Session.EndYear && year <= currentYear; year++ {
+	for year := s.StartYear; year <= s.EndYear && year <= currentYear; year++ {
 		var events []db.Event
-		err := a.getJSONFile(r.Context(), fmt.Sprintf("build/events_attendance_%d.json", year), &events)
+		err := a.getJSONFile(ctx, fmt.Sprintf("build/events_attendance_%d.json", year), &events)
 		if err != nil {
-			if err == storage.ErrObjectNotExist || os.IsNotExist(err) {
+			if isNotExist(err) {
 				continue
 			}
-			log.Print(err)
-			http.Error(w, "Internal Server Error", 500)
-			return
+			return report, err
 		}
 
 		for _, e := range events {
@@ -981,20 +1203,7 @@ func (a *App) ReportAttendance(w http.ResponseWriter, r *http.Request) {
 			for _, i := range e.Items {
 				for _, rollcall := range i.RollCall {
 					hasRollCall = true
-					switch rollcall.ValueID {
-					case 13:
-						// Present
-					case 16:
-						// Absent
-					case 4, 22, 23, 43, 44, 45, 46, 65:
-						// 4: Excused
-						// 22: Maternity
-						// 23: Suspended
-						// 43: Conflict
-						// 44: Paternity
-						// 46: Medical
-						// 45: Jury Duty
-						// 65: Bereavement
+					if rollCallExcused(rollcall.ValueID) {
 						continue
 					}
 					if _, ok := data[rollcall.ID]; !ok {
@@ -1017,44 +1226,24 @@ func (a *App) ReportAttendance(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 			if hasRollCall {
-				body.CountedEvents++
+				report.CountedEvents++
 				if e.BodyID == 1 {
-					body.FullCouncilEvents++
+					report.FullCouncilEvents++
 				}
 			}
 		}
 	}
 
-	for _, r := range data {
+	report.Rows = []AttendanceRow{}
+	for _, id := range order {
+		r := data[id]
 		if r.ExpectedCouncilRollCall > 0 {
 			r.CouncilPercent = (float64(r.CouncilRollCall) / float64(r.ExpectedCouncilRollCall)) * 100
-			if r.CouncilPercent < body.MinCouncilPercent {
-				body.MinCouncilPercent = math.Floor(r.CouncilPercent)
-			}
 		}
 		if r.ExpectedCommitteeRollCall > 0 {
 			r.CommitteePercent = (float64(r.CommitteeRollCall) / float64(r.ExpectedCommitteeRollCall)) * 100
-			if r.CommitteePercent < body.MinCommitteePercent {
-				body.MinCommitteePercent = math.Floor(r.CommitteePercent)
-			}
 		}
+		report.Rows = append(report.Rows, *r)
 	}
-
-	err = a.getJSONFile(r.Context(), "build/last_sync.json", &body.LastSync)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-
-	w.Header().Set("content-type", "text/html")
-	cacheTTL := time.Minute * 15
-	a.addExpireHeaders(w, cacheTTL)
-	err = t.ExecuteTemplate(w, template, body)
-	if err != nil {
-		log.Print(err)
-		http.Error(w, "Internal Server Error", 500)
-		return
-	}
-
+	return report, nil
 }
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,10 +24,7 @@ func (a *App) Search(w http.ResponseWriter, r *http.Request) {
 	body := Page{
 		Page:     "search",
 		Title:    T.Sprintf("NYC Council Legislation Search"),
-		Sessions: Sessions[1:5],
-	}
-	if time.Now().After(time.Date(2026, time.January, 15, 15, 0, 0, 0, time.UTC)) {
-		body.Sessions = Sessions[:5]
+		Sessions: Sessions[:min(len(Sessions), 5)],
 	}
 	err := t.ExecuteTemplate(w, "index.html", body)
 	if err != nil {
@@ -32,3 +33,97 @@ func (a *App) Search(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// SearchAPI returns ranked JSON search results at /api/search
+//
+// Parameters:
+//
+//	q        search terms (required)
+//	session  i.e. 2024-2025 or "all" (default: current session)
+//	type     introduction (default), resolution or all
+//	status   StatusName to filter by i.e. "Enacted" (case insensitive)
+//	limit    max number of results (default 50)
+//	attachments  search the text of attachments instead of legislation: all, committee-report or
+//	             hearing-testimony (comma separated); results link to the attachment
+func (a *App) SearchAPI(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	q := strings.TrimSpace(r.Form.Get("q"))
+	if q == "" {
+		http.Error(w, "missing q", 400)
+		return
+	}
+
+	sessions := []Session{CurrentSession}
+	switch s := r.Form.Get("session"); s {
+	case "":
+	case "all":
+		sessions = Sessions
+	default:
+		sessions = nil
+		for _, ss := range Sessions {
+			if ss.String() == s {
+				sessions = append(sessions, ss)
+			}
+		}
+		if len(sessions) == 0 {
+			http.Error(w, "unknown session", 400)
+			return
+		}
+	}
+	introTypes, err := IntroTypes(r.Form.Get("type"))
+	if err != nil {
+		http.Error(w, "unknown type", 400)
+		return
+	}
+	limit := 50
+	if n, err := strconv.Atoi(r.Form.Get("limit")); err == nil && n > 0 && n <= 500 {
+		limit = n
+	}
+	indexFile := searchIndexFile
+	var attachmentTypes map[string]bool
+	if v := r.Form.Get("attachments"); v != "" {
+		attachmentTypes, err = AttachmentTypes(v)
+		if err != nil {
+			http.Error(w, "unknown attachments", 400)
+			return
+		}
+		indexFile = searchAttachmentsFile
+	}
+	status := r.Form.Get("status")
+	filter := func(e SearchEntry) bool {
+		if status != "" && !strings.EqualFold(e.StatusName, status) {
+			return false
+		}
+		return attachmentTypes == nil || attachmentTypes[e.AttachmentType]
+	}
+
+	type Response struct {
+		Query   string
+		Total   int
+		Results []SearchResult
+	}
+	resp := Response{Query: q, Results: []SearchResult{}}
+	for _, s := range sessions {
+		for _, introType := range introTypes {
+			idx, err := a.getSearchIndex(r.Context(), indexFile(s, introType))
+			if err != nil {
+				if isNotExist(err) {
+					continue
+				}
+				log.Print(err)
+				http.Error(w, "Internal Server Error", 500)
+				return
+			}
+			resp.Results = append(resp.Results, idx.Search(q, filter)...)
+		}
+	}
+	sort.SliceStable(resp.Results, func(i, j int) bool { return resp.Results[i].Score > resp.Results[j].Score })
+	resp.Total = len(resp.Results)
+	if len(resp.Results) > limit {
+		resp.Results = resp.Results[:limit]
+	}
+
+	w.Header().Set("content-type", "application/json")
+	a.addExpireHeaders(w, time.Minute*15)
+	json.NewEncoder(w).Encode(resp)
+}
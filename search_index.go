@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SearchEntry is a row from build/search_index_*.json
+type SearchEntry struct {
+	File         string
+	Name         string
+	Title        string
+	Summary      string
+	StatusName   string
+	LastModified time.Time
+
+	// attachments from build/search_attachments_*.json
+	Attachment     string
+	AttachmentType string
+	URL            string   // i.e. /1234-2024/attachments/hearing-testimony-2-26-24-12345
+	Terms          []string // the distinct words of the attachment
+	Excerpt        string   // the start of the attachment text
+}
+
+func (e SearchEntry) IntroID() IntroID {
+	i, _ := ParseFile(e.File)
+	return i
+}
+
+// field weights used for ranking
+const (
+	weightFile    = 8
+	weightName    = 4
+	weightTitle   = 2
+	weightSummary = 1
+	weightText    = 1
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "by": true, "for": true, "in": true, "of": true,
+	"on": true, "or": true, "the": true, "to": true, "with": true,
+}
+
+// tokenize splits s into lowercase words
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// SearchIndex is an inverted index of SearchEntry
+type SearchIndex struct {
+	Entries []SearchEntry
+	terms   map[string]map[int]float64 // term -> entry -> weight
+}
+
+func NewSearchIndex(entries []SearchEntry) *SearchIndex {
+	s := &SearchIndex{
+		Entries: entries,
+		terms:   make(map[string]map[int]float64),
+	}
+	for i, e := range entries {
+		for _, f := range []struct {
+			text   string
+			weight float64
+		}{
+			{e.File, weightFile},
+			{e.Name, weightName},
+			{e.Title, weightTitle},
+			{e.Summary, weightSummary},
+			{e.Attachment, weightTitle},
+			{strings.Join(e.Terms, " "), weightText},
+		} {
+			// count each term once per field
+			seen := make(map[string]bool)
+			for _, t := range tokenize(f.text) {
+				if stopWords[t] || seen[t] {
+					continue
+				}
+				seen[t] = true
+				p, ok := s.terms[t]
+				if !ok {
+					p = make(map[int]float64)
+					s.terms[t] = p
+				}
+				p[i] += f.weight
+			}
+		}
+	}
+	return s
+}
+
+// SearchResult is a ranked match for a query
+type SearchResult struct {
+	File         string
+	Name         string
+	Title        string
+	StatusName   string
+	LastModified time.Time
+	URL          string
+	Score        float64
+	Snippet      string // HTML escaped with matches wrapped in <mark>
+
+	Attachment     string `json:",omitempty"`
+	AttachmentType string `json:",omitempty"`
+}
+
+// Search returns entries matching all words in q ranked by relevance
+//
+// The last word in q also matches as a prefix (i.e. "bike" matches "bikes")
+func (s *SearchIndex) Search(q string, filter func(SearchEntry) bool) []SearchResult {
+	var words []string
+	for _, t := range tokenize(q) {
+		if !stopWords[t] {
+			words = append(words, t)
+		}
+	}
+	if len(words) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	matched := make(map[string]bool)
+	for i, w := range words {
+		terms := []string{w}
+		if i == len(words)-1 {
+			terms = s.prefixTerms(w)
+		}
+		wordScores := make(map[int]float64)
+		for _, t := range terms {
+			p := s.terms[t]
+			if len(p) == 0 {
+				continue
+			}
+			matched[t] = true
+			idf := math.Log(1 + float64(len(s.Entries))/float64(len(p)))
+			for doc, weight := range p {
+				wordScores[doc] = math.Max(wordScores[doc], weight*idf)
+			}
+		}
+		if scores == nil {
+			scores = wordScores
+			continue
+		}
+		// all words must match
+		for doc := range scores {
+			if v, ok := wordScores[doc]; ok {
+				scores[doc] += v
+			} else {
+				delete(scores, doc)
+			}
+		}
+	}
+
+	var o []SearchResult
+	for doc, score := range scores {
+		e := s.Entries[doc]
+		if filter != nil && !filter(e) {
+			continue
+		}
+		r := SearchResult{
+			File:           e.File,
+			Name:           e.Name,
+			Title:          e.Title,
+			StatusName:     e.StatusName,
+			LastModified:   e.LastModified,
+			URL:            "https://intro.nyc/" + string(e.IntroID()),
+			Score:          math.Round(score*100) / 100,
+			Snippet:        snippet(e, matched),
+			Attachment:     e.Attachment,
+			AttachmentType: e.AttachmentType,
+		}
+		if e.URL != "" {
+			r.URL = "https://intro.nyc" + e.URL
+		}
+		o = append(o, r)
+	}
+	sort.Slice(o, func(i, j int) bool {
+		if o[i].Score == o[j].Score {
+			return o[i].File < o[j].File
+		}
+		return o[i].Score > o[j].Score
+	})
+	return o
+}
+
+func (s *SearchIndex) prefixTerms(w string) []string {
+	var o []string
+	for t := range s.terms {
+		if strings.HasPrefix(t, w) {
+			o = append(o, t)
+		}
+	}
+	return o
+}
+
+const snippetLength = 30
+
+// snippet returns an excerpt of the Excerpt, Summary, Title or Name around the first matched term,
+// or the start of the Excerpt when the match is further into an attachment
+func snippet(e SearchEntry, matched map[string]bool) string {
+	for _, text := range []string{e.Excerpt, e.Summary, e.Title, e.Name} {
+		words := strings.Fields(text)
+		first := -1
+		for i, w := range words {
+			if isMatch(w, matched) {
+				first = i
+				break
+			}
+		}
+		if first == -1 {
+			continue
+		}
+		return snippetWords(words, max(0, first-snippetLength/3), matched)
+	}
+	if e.Excerpt != "" {
+		return snippetWords(strings.Fields(e.Excerpt), 0, matched)
+	}
+	return ""
+}
+
+// snippetWords returns up to snippetLength words from start (HTML escaped) with matches wrapped in <mark>
+func snippetWords(words []string, start int, matched map[string]bool) string {
+	end := min(len(words), start+snippetLength)
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i, w := range words[start:end] {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		if isMatch(w, matched) {
+			fmt.Fprintf(&b, "<mark>%s</mark>", html.EscapeString(w))
+		} else {
+			b.WriteString(html.EscapeString(w))
+		}
+	}
+	if end < len(words) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
+
+func isMatch(word string, matched map[string]bool) bool {
+	for _, t := range tokenize(word) {
+		if matched[t] {
+			return true
+		}
+	}
+	return false
+}
+
+type cachedSearchIndex struct {
+	Set time.Time
+	*SearchIndex
+}
+
+// searchIndexFile returns the build file for a session search index
+// introType is "introduction" or "resolution"
+func searchIndexFile(s Session, introType string) string {
+	if introType == "resolution" {
+		return fmt.Sprintf("build/search_index_resolution_%s.json", s)
+	}
+	return fmt.Sprintf("build/search_index_%s.json", s)
+}
+
+// getSearchIndex returns the (cached) SearchIndex for filename
+func (a *App) getSearchIndex(ctx context.Context, filename string) (*SearchIndex, error) {
+	a.cacheMutex.RLock()
+	if c, ok := a.searchIndexes[filename]; ok && time.Since(c.Set) < time.Minute*15 {
+		a.cacheMutex.RUnlock()
+		return c.SearchIndex, nil
+	}
+	a.cacheMutex.RUnlock()
+
+	var entries []SearchEntry
+	err := a.getJSONFile(ctx, filename, &entries)
+	if err != nil {
+		return nil, err
+	}
+	idx := NewSearchIndex(entries)
+
+	a.cacheMutex.Lock()
+	defer a.cacheMutex.Unlock()
+	a.searchIndexes[filename] = &cachedSearchIndex{Set: time.Now(), SearchIndex: idx}
+	return idx, nil
+}
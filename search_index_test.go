@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSearchIndex(t *testing.T) {
+	entries := []SearchEntry{
+		{File: "Int 0001-2024", Name: "E-bike safety", Title: "A Local Law in relation to electric bicycles", Summary: "This bill would require e-bike batteries to be certified.", StatusName: "Committee"},
+		{File: "Int 0002-2024", Name: "Street trees", Title: "A Local Law in relation to street trees", Summary: "Requires planting trees near bike lanes.", StatusName: "Enacted"},
+	}
+	resolutions := []SearchEntry{
+		{File: "Res 0003-2024", Name: "Parks", Title: "Resolution calling on the state", Summary: "Parks funding.", StatusName: "Adopted"},
+	}
+	body, _ := json.Marshal(entries)
+	resBody, _ := json.Marshal(resolutions)
+	app := newTestApp(t, map[string]string{
+		searchIndexFile(CurrentSession, "introduction"): string(body),
+		searchIndexFile(CurrentSession, "resolution"):   string(resBody),
+	})
+
+	type testCase struct {
+		q      string
+		status string
+		expect []string
+	}
+	tests := []testCase{
+		{q: "bike", expect: []string{"Int 0001-2024", "Int 0002-2024"}},
+		{q: "street trees", expect: []string{"Int 0002-2024"}},
+		{q: "bike", status: "enacted", expect: []string{"Int 0002-2024"}},
+		{q: "bike", status: "ENACTED", expect: []string{"Int 0002-2024"}},
+		{q: "bike", status: "adopted", expect: nil},
+		{q: "0003", expect: []string{"Res 0003-2024"}},
+		{q: "the", expect: nil},
+		{q: "nomatch", expect: nil},
+	}
+	for _, tc := range tests {
+		v := url.Values{"q": {tc.q}, "type": {"all"}}
+		if tc.status != "" {
+			v.Set("status", tc.status)
+		}
+		w := httptest.NewRecorder()
+		app.SearchAPI(w, httptest.NewRequest("GET", "/api/search?"+v.Encode(), nil))
+		if w.Code != 200 {
+			t.Fatalf("%s got status %d", v.Encode(), w.Code)
+		}
+		var resp struct{ Results []SearchResult }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		got := resp.Results
+		if len(got) != len(tc.expect) {
+			t.Errorf("%s got %d results %#v want %v", v.Encode(), len(got), got, tc.expect)
+			continue
+		}
+		for i, r := range got {
+			if r.File != tc.expect[i] {
+				t.Errorf("%s [%d] = %q want %q", v.Encode(), i, r.File, tc.expect[i])
+			}
+		}
+	}
+
+	got := NewSearchIndex(entries).Search("batteries", nil)
+	if len(got) != 1 || got[0].Snippet != "This bill would require e-bike <mark>batteries</mark> to be certified." {
+		t.Errorf("unexpected snippet %#v", got)
+	}
+	if got[0].URL != "https://intro.nyc/0001-2024" {
+		t.Errorf("unexpected URL %q", got[0].URL)
+	}
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 	"time"
 )
 
@@ -49,24 +54,59 @@ func FindSession(year int) Session {
 
 func (s Session) String() string { return fmt.Sprintf("%d-%d", s.StartYear, s.EndYear) }
 
-var Sessions = []Session{
-	{2026, 2029},
-	{2024, 2025},
-	{2022, 2023},
-	{2018, 2021},
-	{2014, 2017},
-	{2010, 2013},
-	{2006, 2009},
-	{2004, 2005},
-	{2002, 2003},
-	{1998, 2001},
+// SessionConfig is an entry in sessions.json
+type SessionConfig struct {
+	StartYear, EndYear int
+	Start              time.Time `json:",omitempty"` // when the session becomes the CurrentSession; default January 1 of StartYear
 }
+
+//go:embed sessions.json
+var sessionsJSON []byte
+
+// Sessions are the sessions that have started, newest first
+var Sessions []Session
 var CurrentSession Session
 
 func init() {
-	if time.Now().After(time.Date(2026, time.January, 7, 15, 0, 0, 0, time.UTC)) {
-		CurrentSession = Sessions[0]
-	} else {
-		CurrentSession = Sessions[1]
+	if err := LoadSessions(bytes.NewReader(sessionsJSON), time.Now()); err != nil {
+		panic(err)
+	}
+}
+
+// LoadSessions replaces Sessions and CurrentSession with the sessions (in sessions.json format) started as of now
+func LoadSessions(r io.Reader, now time.Time) error {
+	var config []SessionConfig
+	if err := json.NewDecoder(r).Decode(&config); err != nil {
+		return fmt.Errorf("invalid sessions %w", err)
+	}
+	sort.Slice(config, func(i, j int) bool { return config[i].StartYear > config[j].StartYear })
+	var started []Session
+	for _, c := range config {
+		if c.EndYear < c.StartYear {
+			return fmt.Errorf("invalid session %d-%d", c.StartYear, c.EndYear)
+		}
+		s := Session{StartYear: c.StartYear, EndYear: c.EndYear}
+		start := c.Start
+		if start.IsZero() {
+			start = s.StartDate()
+		}
+		if !start.After(now) {
+			started = append(started, s)
+		}
+	}
+	if len(started) == 0 {
+		return fmt.Errorf("no sessions started as of %s", now)
+	}
+	Sessions, CurrentSession = started, started[0]
+	return nil
+}
+
+// ParseSession returns the started session matching s (i.e. "2024-2025")
+func ParseSession(s string) (Session, bool) {
+	for _, ss := range Sessions {
+		if ss.String() == s {
+			return ss, true
+		}
 	}
+	return Session{}, false
 }
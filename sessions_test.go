@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSessions(t *testing.T) {
+	defer LoadSessions(strings.NewReader(string(sessionsJSON)), time.Now())
+
+	config := `[
+		{"StartYear": 2022, "EndYear": 2023},
+		{"StartYear": 2030, "EndYear": 2033, "Start": "2030-01-07T15:00:00Z"},
+		{"StartYear": 2026, "EndYear": 2029, "Start": "2026-01-07T15:00:00Z"}
+	]`
+	type testCase struct {
+		now      time.Time
+		current  string
+		sessions int
+	}
+	for _, tc := range []testCase{
+		{time.Date(2026, 1, 7, 14, 0, 0, 0, time.UTC), "2022-2023", 1},
+		{time.Date(2026, 1, 7, 16, 0, 0, 0, time.UTC), "2026-2029", 2},
+		{time.Date(2030, 2, 1, 0, 0, 0, 0, time.UTC), "2030-2033", 3},
+	} {
+		if err := LoadSessions(strings.NewReader(config), tc.now); err != nil {
+			t.Fatal(err)
+		}
+		if CurrentSession.String() != tc.current || len(Sessions) != tc.sessions {
+			t.Errorf("%s got CurrentSession %s %v expected %s", tc.now, CurrentSession, Sessions, tc.current)
+		}
+	}
+	if _, ok := ParseSession("2030-2033"); !ok {
+		t.Errorf("expected 2030-2033 to parse")
+	}
+	if err := LoadSessions(strings.NewReader(`[{"StartYear": 2030, "EndYear": 2033}]`), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("expected error with no started sessions")
+	}
+}
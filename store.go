@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ObjectAttrs describes a stored file
+type ObjectAttrs struct {
+	Name         string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Store is the backing storage for build artifacts and cached files (i.e. gs://intronyc/)
+//
+// Implementations return an error matching fs.ErrNotExist (os.IsNotExist) for missing objects.
+type Store interface {
+	Get(ctx context.Context, name string) (io.ReadCloser, ObjectAttrs, error)
+	Put(ctx context.Context, name, contentType string, r io.Reader) error
+	Stat(ctx context.Context, name string) (ObjectAttrs, error)
+}
+
+// NewStore returns a Store for uri
+//
+//	gs://bucket     Google Cloud Storage
+//	s3://bucket     S3 compatible storage (See NewS3Store)
+//	file:///path    local directory (a bare path is also accepted)
+//	memory:         in-memory; contents are lost on exit
+func NewStore(ctx context.Context, uri string) (Store, error) {
+	switch {
+	case strings.HasPrefix(uri, "s3://"):
+		return NewS3Store(os.Getenv("S3_ENDPOINT"), strings.TrimSuffix(strings.TrimPrefix(uri, "s3://"), "/"))
+	case strings.HasPrefix(uri, "gs://"):
+		client, err := storage.NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &GCSStore{Client: client, Bucket: strings.TrimSuffix(strings.TrimPrefix(uri, "gs://"), "/")}, nil
+	case uri == "memory:":
+		return NewMemoryStore(), nil
+	case strings.HasPrefix(uri, "file://"):
+		return DirStore(strings.TrimPrefix(uri, "file://")), nil
+	case uri != "" && !strings.Contains(uri, "://"):
+		return DirStore(uri), nil
+	}
+	return nil, fmt.Errorf("unknown store %q", uri)
+}
+
+// GCSStore is a Store backed by a Google Cloud Storage bucket
+type GCSStore struct {
+	Client *storage.Client
+	Bucket string
+}
+
+func (g *GCSStore) Get(ctx context.Context, name string) (io.ReadCloser, ObjectAttrs, error) {
+	log.Printf("get gs://%s/%s", g.Bucket, name)
+	r, err := g.Client.Bucket(g.Bucket).Object(name).NewReader(ctx)
+	if err != nil {
+		return nil, ObjectAttrs{}, gcsError(name, err)
+	}
+	return r, ObjectAttrs{
+		Name:         name,
+		Size:         r.Attrs.Size,
+		ContentType:  r.Attrs.ContentType,
+		LastModified: r.Attrs.LastModified,
+	}, nil
+}
+
+func (g *GCSStore) Put(ctx context.Context, name, contentType string, r io.Reader) error {
+	log.Printf("put gs://%s/%s", g.Bucket, name)
+	// cancelling ctx before Close aborts the upload on a partial copy
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w := g.Client.Bucket(g.Bucket).Object(name).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		cancel()
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (g *GCSStore) Stat(ctx context.Context, name string) (ObjectAttrs, error) {
+	a, err := g.Client.Bucket(g.Bucket).Object(name).Attrs(ctx)
+	if err != nil {
+		return ObjectAttrs{}, gcsError(name, err)
+	}
+	return ObjectAttrs{Name: name, Size: a.Size, ContentType: a.ContentType, LastModified: a.Updated}, nil
+}
+
+// gcsError maps storage.ErrObjectNotExist to fs.ErrNotExist
+func gcsError(name string, err error) error {
+	if err == storage.ErrObjectNotExist {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return err
+}
+
+// S3Store is a Store backed by an S3 compatible bucket (i.e. AWS S3, MinIO, Cloudflare R2)
+type S3Store struct {
+	Client *minio.Client
+	Bucket string
+}
+
+// NewS3Store returns a Store for bucket at endpoint (i.e. https://s3.us-east-1.amazonaws.com or
+// http://localhost:9000); the default is AWS S3.
+//
+// Credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY (or MINIO_ACCESS_KEY and
+// MINIO_SECRET_KEY) falling back to an IAM role. AWS_REGION is used when set.
+func NewS3Store(endpoint, bucket string) (*S3Store, error) {
+	if endpoint == "" {
+		endpoint = "https://s3.amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	client, err := minio.New(u.Host, &minio.Options{
+		Creds: credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.IAM{},
+		}),
+		Secure:       u.Scheme != "http",
+		Region:       os.Getenv("AWS_REGION"),
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{Client: client, Bucket: bucket}, nil
+}
+
+func (s *S3Store) Get(ctx context.Context, name string) (io.ReadCloser, ObjectAttrs, error) {
+	log.Printf("get s3://%s/%s", s.Bucket, name)
+	o, err := s.Client.GetObject(ctx, s.Bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectAttrs{}, s3Error(name, err)
+	}
+	// GetObject is lazy; Stat makes the request
+	info, err := o.Stat()
+	if err != nil {
+		o.Close()
+		return nil, ObjectAttrs{}, s3Error(name, err)
+	}
+	return o, s3Attrs(name, info), nil
+}
+
+// Put reads r into memory so the size is known; objects under the multipart threshold are
+// uploaded with a single request
+func (s *S3Store) Put(ctx context.Context, name, contentType string, r io.Reader) error {
+	log.Printf("put s3://%s/%s", s.Bucket, name)
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.PutObject(ctx, s.Bucket, name, bytes.NewReader(body), int64(len(body)), minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Store) Stat(ctx context.Context, name string) (ObjectAttrs, error) {
+	info, err := s.Client.StatObject(ctx, s.Bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectAttrs{}, s3Error(name, err)
+	}
+	return s3Attrs(name, info), nil
+}
+
+func s3Attrs(name string, info minio.ObjectInfo) ObjectAttrs {
+	return ObjectAttrs{Name: name, Size: info.Size, ContentType: info.ContentType, LastModified: info.LastModified}
+}
+
+// s3Error maps a missing key to fs.ErrNotExist
+func s3Error(name string, err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return err
+}
+
+// DirStore is a Store backed by a local directory
+type DirStore string
+
+// path returns the local path for name; name can not escape the directory
+func (d DirStore) path(name string) string {
+	return filepath.Join(string(d), filepath.FromSlash(path.Clean("/"+name)))
+}
+
+func (d DirStore) Get(ctx context.Context, name string) (io.ReadCloser, ObjectAttrs, error) {
+	fp := d.path(name)
+	log.Printf("opening %s", fp)
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, ObjectAttrs{}, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, ObjectAttrs{}, err
+	}
+	return f, dirAttrs(name, fi), nil
+}
+
+func (d DirStore) Put(ctx context.Context, name, contentType string, r io.Reader) error {
+	fp := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(fp), 0750); err != nil {
+		return err
+	}
+	// write to a temp file so readers never see a partial file
+	f, err := os.CreateTemp(filepath.Dir(fp), ".tmp-"+filepath.Base(fp))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fp)
+}
+
+func (d DirStore) Stat(ctx context.Context, name string) (ObjectAttrs, error) {
+	fp := d.path(name)
+	fi, err := os.Stat(fp)
+	if err != nil {
+		return ObjectAttrs{}, err
+	}
+	return dirAttrs(name, fi), nil
+}
+
+func dirAttrs(name string, fi fs.FileInfo) ObjectAttrs {
+	return ObjectAttrs{
+		Name:         name,
+		Size:         fi.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(name)),
+		LastModified: fi.ModTime(),
+	}
+}
+
+// MemoryStore is an in-memory Store; useful for tests
+type MemoryStore struct {
+	sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	Body  []byte
+	Attrs ObjectAttrs
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, name string) (io.ReadCloser, ObjectAttrs, error) {
+	m.RLock()
+	defer m.RUnlock()
+	o, ok := m.objects[name]
+	if !ok {
+		return nil, ObjectAttrs{}, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(o.Body)), o.Attrs, nil
+}
+
+func (m *MemoryStore) Put(ctx context.Context, name, contentType string, r io.Reader) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.objects[name] = memoryObject{
+		Body: body,
+		Attrs: ObjectAttrs{
+			Name:         name,
+			Size:         int64(len(body)),
+			ContentType:  contentType,
+			LastModified: time.Now().UTC().Truncate(time.Second),
+		},
+	}
+	return nil
+}
+
+func (m *MemoryStore) Stat(ctx context.Context, name string) (ObjectAttrs, error) {
+	m.RLock()
+	defer m.RUnlock()
+	o, ok := m.objects[name]
+	if !ok {
+		return ObjectAttrs{}, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return o.Attrs, nil
+}
+
+// isNotExist reports if err indicates a missing object from any Store
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 serves GET, HEAD and PUT for path style S3 requests
+func fakeS3(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	types := make(map[string]string)
+	modified := time.Now().UTC().Truncate(time.Second)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "PUT":
+			body, _ := io.ReadAll(r.Body)
+			if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+				body = decodeAWSChunked(body)
+			}
+			objects[r.URL.Path], types[r.URL.Path] = body, r.Header.Get("Content-Type")
+			w.Header().Set("ETag", `"etag"`)
+		case "GET", "HEAD":
+			body, ok := objects[r.URL.Path]
+			if !ok {
+				w.Header().Set("Content-Type", "application/xml")
+				w.WriteHeader(404)
+				fmt.Fprint(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+				return
+			}
+			w.Header().Set("ETag", `"etag"`)
+			w.Header().Set("Content-Type", types[r.URL.Path])
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			if r.Method == "GET" {
+				w.Write(body)
+			}
+		default:
+			w.WriteHeader(405)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// decodeAWSChunked removes the chunk headers of a streaming signed upload
+//
+//	$size;chunk-signature=$signature\r\n$data\r\n ... 0;chunk-signature=$signature\r\n\r\n
+func decodeAWSChunked(body []byte) []byte {
+	var o []byte
+	for {
+		header, rest, ok := strings.Cut(string(body), "\r\n")
+		if !ok {
+			return o
+		}
+		hexSize, _, _ := strings.Cut(header, ";")
+		n, err := strconv.ParseInt(hexSize, 16, 64)
+		if err != nil || n == 0 || int(n) > len(rest) {
+			return o
+		}
+		o = append(o, rest[:n]...)
+		body = []byte(strings.TrimPrefix(rest[n:], "\r\n"))
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("S3_ENDPOINT", fakeS3(t).URL)
+	s3, err := NewStore(ctx, "s3://intronyc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]Store{
+		"memory": NewMemoryStore(),
+		"dir":    DirStore(t.TempDir()),
+		"s3":     s3,
+	}
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Stat(ctx, "build/missing.json"); !isNotExist(err) {
+				t.Fatalf("Stat(missing) err = %v, want not exist", err)
+			}
+			if _, _, err := s.Get(ctx, "build/missing.json"); !isNotExist(err) {
+				t.Fatalf("Get(missing) err = %v, want not exist", err)
+			}
+			if err := s.Put(ctx, "build/a.json", "application/json", strings.NewReader(`{"a":1}`)); err != nil {
+				t.Fatal(err)
+			}
+			rc, attrs, err := s.Get(ctx, "build/a.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			body, _ := io.ReadAll(rc)
+			if string(body) != `{"a":1}` {
+				t.Errorf("got body %q", body)
+			}
+			if attrs.Size != 7 || attrs.LastModified.IsZero() {
+				t.Errorf("got attrs %#v", attrs)
+			}
+			if _, err := s.Stat(ctx, "build/a.json"); err != nil {
+				t.Errorf("Stat err %v", err)
+			}
+		})
+	}
+}
+
+func newTestApp(t *testing.T, files map[string]string) *App {
+	t.Helper()
+	store := NewMemoryStore()
+	for name, body := range files {
+		if err := store.Put(context.Background(), name, "", strings.NewReader(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &App{
+		store:              store,
+		devMode:            true,
+		templateFS:         content,
+		cachedRedirects:    make(map[IntroID]string),
+		cachedLegislation:  make(map[IntroID]*CachedLegislation),
+		cachedTextVersions: make(map[IntroID]*CachedTextVersions),
+		searchIndexes:      make(map[string]*cachedSearchIndex),
+		fileCache:          make(map[string]CachedFile),
+	}
+}
+
+func TestProxyJSON(t *testing.T) {
+	app := newTestApp(t, map[string]string{"build/last_sync.json": `{"LastRun":"2024-01-02T00:00:00Z"}`})
+	router := http.NewServeMux()
+	router.HandleFunc("GET /data/{path}", app.ProxyJSON)
+
+	for path, code := range map[string]int{
+		"/data/last_sync.json": 200,
+		"/data/missing.json":   404,
+		"/data/last_sync.txt":  404,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != code {
+			t.Errorf("GET %s = %d, want %d", path, w.Code, code)
+		}
+	}
+}
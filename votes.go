@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jehiah/legislator/db"
+)
+
+// RollCall is a vote on legislation with the position of each council member (/votes/$file/$id.json)
+type RollCall struct {
+	ID             int // History ID
+	File           string
+	Name           string
+	Action         string
+	Date           time.Time
+	BodyName       string
+	PassedFlagName string // "Pass" or "Fail"
+	Summary        string // "ayes:abstains:nays"
+	Votes          []RollCallVote
+}
+
+// RollCallVote is a single council member's vote
+type RollCallVote struct {
+	Councilmember      db.PersonReference
+	Vote               string // i.e. "Affirmative", "Negative", "Abstain", "Absent"
+	Result             int    // 1 affirmative; 2 negative
+	Party              string // "D", "R"
+	Borough            string
+	Sponsor            bool
+	SponsorVotedNo     bool // a sponsor who voted against the legislation
+	NonSponsorVotedYes bool // voted for the legislation without sponsoring it
+}
+
+func (v RollCallVote) CSSClass() string {
+	switch v.Result {
+	case 1:
+		return "affirmative"
+	case 2:
+		return "negative"
+	}
+	return "absent"
+}
+
+// NewRollCall combines the votes on h with the party and borough for each council member
+func NewRollCall(l Legislation, h History, people []db.Person) RollCall {
+	lookup := make(map[int]Person, len(people))
+	for _, p := range people {
+		lookup[p.ID] = Person{Person: p}
+	}
+	r := RollCall{
+		ID:             h.ID,
+		File:           l.File,
+		Name:           l.Name,
+		Action:         h.Action,
+		Date:           h.Date,
+		BodyName:       h.BodyName,
+		PassedFlagName: h.PassedFlagName,
+		Summary:        h.VoteSummary(),
+		Votes:          []RollCallVote{},
+	}
+	for _, v := range h.Votes {
+		p := lookup[v.ID]
+		rv := RollCallVote{
+			Councilmember: v.PersonReference,
+			Vote:          v.Vote,
+			Result:        v.Result,
+			Party:         p.PartyShort(),
+			Borough:       p.Borough(),
+			Sponsor:       l.SponsoredBy(v.ID),
+		}
+		rv.SponsorVotedNo = rv.Sponsor && v.Vote == "Negative"
+		rv.NonSponsorVotedYes = !rv.Sponsor && v.Vote == "Affirmative"
+		r.Votes = append(r.Votes, rv)
+	}
+	return r
+}
+
+// Votes shows each council member's vote on a roll call at /votes/$file/$history_id (or /votes/$file/$history_id.json)
+//
+// Sponsors who voted no, and council members who voted yes without sponsoring, are highlighted
+func (a *App) Votes(w http.ResponseWriter, r *http.Request) {
+	id, err := ParseIntroID(r.PathValue("file"))
+	if err != nil {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	historyID, err := strconv.Atoi(strings.TrimSuffix(r.PathValue("id"), ".json"))
+	if err != nil || historyID <= 0 {
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	ctx := r.Context()
+
+	l, err := a.GetLegislation(ctx, id)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	if l == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+	var history *History
+	for _, h := range l.History {
+		if h.ID == historyID {
+			history = &History{h}
+			break
+		}
+	}
+	if history == nil {
+		a.addExpireHeaders(w, time.Minute*5)
+		http.Error(w, "Not Found", 404)
+		return
+	}
+
+	var people []db.Person
+	err = a.getJSONFile(ctx, "build/people_all.json", &people)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+	rollCall := NewRollCall(*l, *history, people)
+
+	ttl := time.Hour
+	if l.IntroDate.Year() < CurrentSession.StartYear {
+		ttl = time.Hour * 48
+	}
+	a.addExpireHeaders(w, ttl)
+
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rollCall)
+		return
+	}
+
+	type Page struct {
+		Page        string
+		Legislation Legislation
+		RollCall    RollCall
+	}
+	body := Page{
+		Legislation: *l,
+		RollCall:    rollCall,
+	}
+	t := newTemplate(a.templateFS, "votes.html")
+	w.Header().Set("content-type", "text/html")
+	err = t.ExecuteTemplate(w, "votes.html", body)
+	if err != nil {
+		log.Print(err)
+		http.Error(w, "Internal Server Error", 500)
+		return
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVotes(t *testing.T) {
+	year := CurrentSession.StartYear
+	app := newTestApp(t, map[string]string{
+		"build/people_all.json": `[
+			{"ID":1,"Slug":"jane-doe","FullName":"Jane Doe","WWW":"https://council.nyc.gov/district-1/",
+				"OfficeRecords":[{"BodyName":"Democratic Conference of the Council of the City of New York "}]},
+			{"ID":2,"Slug":"john-roe","FullName":"John Roe","WWW":"https://council.nyc.gov/district-50/",
+				"OfficeRecords":[{"BodyName":"Minority (Republican) Conference of the Council of the City of New York "}]},
+			{"ID":3,"Slug":"sam-poe","FullName":"Sam Poe"}]`,
+	})
+	app.offline = true
+	app.archive = NewMemoryStore()
+	app.archive.Put(context.Background(), fmt.Sprintf("introduction/%d/0001.json", year), "", strings.NewReader(fmt.Sprintf(`{
+		"File":"Int 0001-%d","Name":"Bike Lanes","Sponsors":[{"ID":1},{"ID":2}],
+		"History":[{"ID":42,"Action":"Approved by Council","PassedFlagName":"Pass","Votes":[
+			{"ID":1,"FullName":"Jane Doe","Slug":"jane-doe","Vote":"Affirmative","Result":1},
+			{"ID":2,"FullName":"John Roe","Slug":"john-roe","Vote":"Negative","Result":2},
+			{"ID":3,"FullName":"Sam Poe","Slug":"sam-poe","Vote":"Affirmative","Result":1}]}]}`, year)))
+
+	file := fmt.Sprintf("0001-%d", year)
+	get := func(file, id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/votes/"+file+"/"+id, nil)
+		r.SetPathValue("file", file)
+		r.SetPathValue("id", id)
+		app.Votes(w, r)
+		return w
+	}
+
+	w := get(file, "42.json")
+	if w.Code != 200 {
+		t.Fatalf("status %d %s", w.Code, w.Body)
+	}
+	var rollCall RollCall
+	if err := json.Unmarshal(w.Body.Bytes(), &rollCall); err != nil {
+		t.Fatal(err)
+	}
+	if rollCall.Summary != "2:0:1" || len(rollCall.Votes) != 3 {
+		t.Fatalf("unexpected roll call %#v", rollCall)
+	}
+	for i, expected := range []RollCallVote{
+		{Party: "D", Borough: "Manhattan", Sponsor: true},
+		{Party: "R", Borough: "Staten Island", Sponsor: true, SponsorVotedNo: true},
+		{NonSponsorVotedYes: true},
+	} {
+		v := rollCall.Votes[i]
+		if v.Party != expected.Party || v.Borough != expected.Borough || v.Sponsor != expected.Sponsor ||
+			v.SponsorVotedNo != expected.SponsorVotedNo || v.NonSponsorVotedYes != expected.NonSponsorVotedYes {
+			t.Errorf("vote %d got %#v", i, v)
+		}
+	}
+
+	w = get(file, "42")
+	if w.Code != 200 || !strings.Contains(w.Body.String(), "Bike Lanes") {
+		t.Errorf("status %d %s", w.Code, w.Body)
+	}
+
+	for _, tc := range [][2]string{{file, "43"}, {"0002-" + file[5:], "42"}, {"x", "42"}} {
+		if w := get(tc[0], tc[1]); w.Code != 404 {
+			t.Errorf("GET /votes/%s/%s = %d, want 404", tc[0], tc[1], w.Code)
+		}
+	}
+}